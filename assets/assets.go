@@ -0,0 +1,116 @@
+// Package assets finds the game's sprites, sprite sheets and sounds by
+// logical name, through a manifest, in any filesystem. That is the
+// directory the game is run from while developing, or the files built into
+// the binary in release builds.
+package assets
+
+import (
+	"encoding/json"
+	"io/fs"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ManifestPath is where the manifest is in the filesystem.
+const ManifestPath = "assets.json"
+
+// Manifest maps the logical names of assets to their paths.
+type Manifest struct {
+	// Sprites are single images.
+	Sprites map[string]string `json:"sprites"`
+	// Sheets are sprite sheet descriptors, see graphics.LoadSheet.
+	Sheets map[string]string `json:"sheets"`
+	// Sounds are audio files.
+	Sounds map[string]string `json:"sounds"`
+	// Playlist is the background music, in order: audio files, and
+	// directories whose audio files are all played.
+	Playlist []string `json:"playlist"`
+}
+
+// Assets are a filesystem and the manifest of the assets in it.
+type Assets struct {
+	FS fs.FS
+	Manifest
+}
+
+// Open reads the manifest of the assets in fsys.
+func Open(fsys fs.FS) (a *Assets, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error loading asset manifest")
+		}
+	}()
+
+	data, err := fs.ReadFile(fsys, ManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	a = &Assets{FS: fsys}
+	if err := json.Unmarshal(data, &a.Manifest); err != nil {
+		return nil, err
+	}
+	if err := a.Manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Validate checks that every path in the manifest is one a filesystem can
+// open. It doesn't check that the files exist.
+func (m *Manifest) Validate() error {
+	for _, kind := range []struct {
+		name  string
+		paths map[string]string
+	}{
+		{"sprite", m.Sprites},
+		{"sheet", m.Sheets},
+		{"sound", m.Sounds},
+	} {
+		for name, path := range kind.paths {
+			if !fs.ValidPath(path) {
+				return errors.Errorf("%s %q: %q is not a slash-separated path relative to the assets", kind.name, name, path)
+			}
+		}
+	}
+	for _, path := range m.Playlist {
+		if !fs.ValidPath(path) {
+			return errors.Errorf("playlist: %q is not a slash-separated path relative to the assets", path)
+		}
+	}
+	return nil
+}
+
+// Sprite returns the path of the named sprite.
+func (m *Manifest) Sprite(name string) string { return lookup("sprite", m.Sprites, name) }
+
+// Sheet returns the path of the named sprite sheet's descriptor.
+func (m *Manifest) Sheet(name string) string { return lookup("sheet", m.Sheets, name) }
+
+// Sound returns the path of the named sound.
+func (m *Manifest) Sound(name string) string { return lookup("sound", m.Sounds, name) }
+
+// lookup panics on unknown names, since the names are in the code and the
+// manifest ships with it.
+func lookup(kind string, paths map[string]string, name string) string {
+	path, ok := paths[name]
+	if !ok {
+		panic("assets: no " + kind + " named " + strconv.Quote(name) + " in the manifest")
+	}
+	return path
+}
+
+// Paths returns the path of every asset in the manifest, sorted. Playlist
+// directories are included as they are.
+func (m *Manifest) Paths() []string {
+	var paths []string
+	for _, kind := range []map[string]string{m.Sprites, m.Sheets, m.Sounds} {
+		for _, path := range kind {
+			paths = append(paths, path)
+		}
+	}
+	paths = append(paths, m.Playlist...)
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,35 @@
+package assets
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestOpen(t *testing.T) {
+	fsys := fstest.MapFS{
+		ManifestPath: {Data: []byte(`{
+			"sprites": {"logo": "graphics/logo.png"},
+			"sheets": {"player": "graphics/player.json"},
+			"sounds": {"crash": "crash.mp3"}
+		}`)},
+	}
+	a, err := Open(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Sheet("player"); got != "graphics/player.json" {
+		t.Errorf("player sheet is %q, want graphics/player.json", got)
+	}
+	want := []string{"crash.mp3", "graphics/logo.png", "graphics/player.json"}
+	if got := a.Paths(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("paths are %q, want %q", got, want)
+	}
+
+	fsys[ManifestPath] = &fstest.MapFile{Data: []byte(`{"sounds": {"crash": "../crash.mp3"}}`)}
+	if _, err := Open(fsys); err == nil {
+		t.Error("opened a manifest with a path outside the assets")
+	}
+	if _, err := Open(fstest.MapFS{}); err == nil {
+		t.Error("opened assets without a manifest")
+	}
+}
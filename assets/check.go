@@ -0,0 +1,220 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+	"storj.io/snoboard/audio"
+	"storj.io/snoboard/graphics"
+)
+
+// maxVariant is the highest resolution variant of an image that is checked.
+const maxVariant = 3
+
+// Problem is something wrong with an asset.
+type Problem struct {
+	Path string
+	Err  error
+	// Warning is set for problems the game runs despite, like unused files.
+	Warning bool
+}
+
+func (p Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %v", level, p.Path, p.Err)
+}
+
+// Check loads every asset in the manifest the way the game would and reports
+// what is wrong with them: missing files, images and sounds that don't
+// decode, sprite sheets with frames outside their image, and @2x or @3x
+// variants whose size doesn't match the original. Files in the unusedIn
+// directories that no asset uses are reported as warnings. Problems are
+// sorted by path.
+func (a *Assets) Check(unusedIn ...string) []Problem {
+	c := &checker{fsys: a.FS, used: make(map[string]bool)}
+	for _, name := range sortedKeys(a.Sprites) {
+		c.checkImage(a.Sprites[name])
+	}
+	for _, name := range sortedKeys(a.Sheets) {
+		c.checkSheet(a.Sheets[name])
+	}
+	for _, name := range sortedKeys(a.Sounds) {
+		c.checkSound(a.Sounds[name])
+	}
+	for _, name := range a.Playlist {
+		c.checkPlaylist(name)
+	}
+	for _, dir := range unusedIn {
+		c.checkUnused(dir)
+	}
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		return c.problems[i].Path < c.problems[j].Path
+	})
+	return c.problems
+}
+
+type checker struct {
+	fsys     fs.FS
+	used     map[string]bool
+	problems []Problem
+}
+
+func (c *checker) errorf(path string, err error) {
+	c.problems = append(c.problems, Problem{Path: path, Err: err})
+}
+
+// checkImage decodes an image and each of its variants, and returns the
+// image's bounds.
+func (c *checker) checkImage(name string) (pixel.Rect, bool) {
+	c.used[name] = true
+	pic, err := graphics.LoadPicture(c.fsys, name)
+	if err != nil {
+		c.errorf(name, err)
+		return pixel.Rect{}, false
+	}
+
+	bounds := pic.Bounds()
+	for scale := 2; scale <= maxVariant; scale++ {
+		variant, density := graphics.ScaledPath(c.fsys, name, float64(scale))
+		if int(density) != scale {
+			continue
+		}
+		c.used[variant] = true
+		pic, err := graphics.LoadPicture(c.fsys, variant)
+		if err != nil {
+			c.errorf(variant, err)
+			continue
+		}
+		if want := bounds.Size().Scaled(density); pic.Bounds().Size() != want {
+			c.errorf(variant, errors.Errorf("is %vx%v, but %s is %vx%v so it should be %vx%v",
+				pic.Bounds().W(), pic.Bounds().H(), name, bounds.W(), bounds.H(), want.X, want.Y))
+		}
+	}
+	return bounds, true
+}
+
+// checkSheet loads a sprite sheet at every scale it has a variant for.
+func (c *checker) checkSheet(name string) {
+	c.used[name] = true
+	data, err := fs.ReadFile(c.fsys, name)
+	if err != nil {
+		c.errorf(name, err)
+		return
+	}
+	var desc struct {
+		Meta struct {
+			Image string `json:"image"`
+		} `json:"meta"`
+	}
+	if json.Unmarshal(data, &desc) == nil && desc.Meta.Image != "" {
+		if _, ok := c.checkImage(path.Join(path.Dir(name), desc.Meta.Image)); !ok {
+			return
+		}
+	}
+
+	for scale := 1; scale <= maxVariant; scale++ {
+		if _, _, err := graphics.LoadScaledSheet(c.fsys, name, float64(scale)); err != nil {
+			c.errorf(name, err)
+			return
+		}
+	}
+}
+
+// checkSound decodes the whole of a sound.
+func (c *checker) checkSound(name string) {
+	c.used[name] = true
+	streamer, _, err := audio.Decode(c.fsys, name)
+	if err != nil {
+		c.errorf(name, err)
+		return
+	}
+	defer streamer.Close()
+
+	samples := make([][2]float64, 4096)
+	for {
+		if _, ok := streamer.Stream(samples); !ok {
+			break
+		}
+	}
+	if err := streamer.Err(); err != nil {
+		c.errorf(name, err)
+	}
+}
+
+// checkPlaylist checks a track of the playlist, or every track in a
+// directory of them.
+func (c *checker) checkPlaylist(name string) {
+	info, err := fs.Stat(c.fsys, name)
+	if err != nil {
+		c.errorf(name, err)
+		return
+	}
+	if !info.IsDir() {
+		c.checkSound(name)
+		return
+	}
+	entries, err := fs.ReadDir(c.fsys, name)
+	if err != nil {
+		c.errorf(name, err)
+		return
+	}
+	tracks := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if audio.IsSound(entry.Name()) {
+			c.checkSound(path.Join(name, entry.Name()))
+			tracks++
+		}
+	}
+	if tracks == 0 {
+		c.errorf(name, errors.New("playlist directory has no MP3, OGG, WAV or FLAC files"))
+	}
+}
+
+// checkUnused warns about files below dir that no asset uses. Go source and
+// hidden files are left alone.
+func (c *checker) checkUnused(dir string) {
+	err := fs.WalkDir(c.fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() && name != dir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || path.Ext(name) == ".go" || c.used[name] {
+			return nil
+		}
+		c.problems = append(c.problems, Problem{
+			Path:    name,
+			Err:     errors.New("not used by any asset in the manifest"),
+			Warning: true,
+		})
+		return nil
+	})
+	if err != nil {
+		c.errorf(dir, err)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
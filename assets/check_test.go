@@ -0,0 +1,65 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func pngFile(t *testing.T, w, h int) *fstest.MapFile {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
+
+func TestCheck(t *testing.T) {
+	fsys := fstest.MapFS{
+		"graphics/logo.png":        pngFile(t, 10, 10),
+		"graphics/hd/logo@2x.png":  pngFile(t, 20, 18),
+		"graphics/broken.png":      {Data: []byte("not a png")},
+		"graphics/sheet.png":       pngFile(t, 64, 32),
+		"graphics/sheet@2x.png":    pngFile(t, 128, 64),
+		"graphics/old.png":         pngFile(t, 1, 1),
+		"graphics/.DS_Store":       {Data: []byte{0}},
+		"graphics/sheet_loader.go": {Data: []byte("package graphics")},
+		"music/README":             {Data: []byte("tracks go here")},
+		"graphics/sheet.json": {Data: []byte(`{
+			"frames": [{"frame": {"x": 32, "y": 0, "w": 64, "h": 32}}],
+			"meta": {"image": "sheet.png"}
+		}`)},
+	}
+	a := &Assets{FS: fsys, Manifest: Manifest{
+		Sprites: map[string]string{
+			"logo":    "graphics/logo.png",
+			"broken":  "graphics/broken.png",
+			"missing": "graphics/missing.png",
+		},
+		Sheets:   map[string]string{"sheet": "graphics/sheet.json"},
+		Playlist: []string{"music", "missing.ogg"},
+	}}
+
+	want := []string{
+		"error: graphics/broken.png: image: unknown format",
+		"error: graphics/hd/logo@2x.png: is 20x18, but graphics/logo.png is 10x10 so it should be 20x20",
+		"error: graphics/missing.png: open graphics/missing.png: file does not exist",
+		"warning: graphics/old.png: not used",
+		"error: graphics/sheet.json: error loading sprite sheet: graphics/sheet.json:2:",
+		"error: missing.ogg: open missing.ogg: file does not exist",
+		"error: music: playlist directory has no MP3, OGG, WAV or FLAC files",
+	}
+	problems := a.Check("graphics")
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i, p := range problems {
+		if !strings.HasPrefix(p.String(), want[i]) {
+			t.Errorf("problem %d is %q, want it to start with %q", i, p, want[i])
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !release
+// +build !release
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"storj.io/snoboard/assets"
+)
+
+var assetsDir = flag.String("assets", "", "load assets from this directory instead of the working directory or the one the game is in")
+
+// openAssets opens the assets on disk, so that they can be edited while the
+// game runs. It returns the directory they are in.
+func openAssets() (*assets.Assets, string, error) {
+	dir := findAssets()
+	a, err := assets.Open(os.DirFS(dir))
+	return a, dir, err
+}
+
+// findAssets returns the directory given by -assets, or else the working
+// directory if it holds a manifest, or else the directory of the executable.
+func findAssets() string {
+	if *assetsDir != "" {
+		return *assetsDir
+	}
+	if _, err := os.Stat(assets.ManifestPath); err == nil {
+		return "."
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+	return filepath.Dir(exe)
+}
@@ -0,0 +1,28 @@
+//go:build release
+// +build release
+
+package main
+
+import (
+	"embed"
+
+	"storj.io/snoboard/assets"
+)
+
+// embedded holds every file the manifest refers to, along with the @2x
+// variants of the sprite sheets and the whole music directory.
+//
+//go:embed assets.json
+//go:embed graphics/snowtile.png
+//go:embed graphics/dj/danger_zone.png
+//go:embed graphics/dj/player.json graphics/dj/player.png graphics/dj/player@2x.png
+//go:embed graphics/dj/obstacles.json graphics/dj/obstacles.png graphics/dj/obstacles@2x.png
+//go:embed dead.mp3 sounds/server_hum.wav sounds/disk_whine.wav music
+var embedded embed.FS
+
+// openAssets opens the assets built into the binary. They aren't on disk, so
+// there is no directory to watch.
+func openAssets() (*assets.Assets, string, error) {
+	a, err := assets.Open(embedded)
+	return a, "", err
+}
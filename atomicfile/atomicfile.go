@@ -0,0 +1,23 @@
+// Package atomicfile replaces files whole, so that the game's saved state is
+// never left half written.
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to the file at path, creating its directory if
+// needed. The data goes to a temporary file first, which is then renamed
+// over path, so a crash leaves either the old file or the new one.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
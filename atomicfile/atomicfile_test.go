@@ -0,0 +1,33 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snoboard", "state.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("file holds %q, want %q", got, data)
+		}
+	}
+
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("directory holds %d files, want just the one written", len(files))
+	}
+}
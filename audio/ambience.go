@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"io/fs"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/pkg/errors"
+)
+
+// How a source's place relative to the listener is heard.
+const (
+	// HearingDistance is how far away a source can be heard from. Sources
+	// fade out towards it, so they come and go smoothly.
+	HearingDistance = 900.0
+	// panDistance is how far to the side a source has to be to be heard
+	// from one speaker only.
+	panDistance = 600.0
+	// placeGlide is how long a source takes to settle at a new place, so
+	// that moving it once a frame doesn't crackle.
+	placeGlide = 30 * time.Millisecond
+)
+
+// Ambience plays looping sounds from sources placed around the listener,
+// panned and quieter with distance, like the hum of a server rack
+// approaching from the left.
+type Ambience struct {
+	bus    *Bus
+	fsys   fs.FS
+	paths  map[string]string
+	sounds map[string]*beep.Buffer
+}
+
+// LoadAmbience decodes the sounds, by name, from fsys to be played on bus.
+// The sounds should loop without a seam.
+func LoadAmbience(fsys fs.FS, bus *Bus, paths map[string]string) (*Ambience, error) {
+	a := &Ambience{bus: bus, fsys: fsys, paths: paths}
+	sounds, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+	a.sounds = sounds
+	return a, nil
+}
+
+func (a *Ambience) load() (map[string]*beep.Buffer, error) {
+	sounds := make(map[string]*beep.Buffer, len(a.paths))
+	for name, path := range a.paths {
+		buffer, err := bufferEffect(a.fsys, Effect{Path: path})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading ambient sound %q", name)
+		}
+		sounds[name] = buffer
+	}
+	return sounds, nil
+}
+
+// Start starts the named sound looping from a new source. It is silent until
+// it is placed. Each source starts from a random point in the loop, so that
+// several playing the same sound don't play in step.
+func (a *Ambience) Start(name string) *Source {
+	buffer, ok := a.sounds[name]
+	if !ok {
+		panic("audio: no ambient sound named " + strconv.Quote(name))
+	}
+	loop := beep.Seq(
+		buffer.Streamer(rand.Intn(buffer.Len()), buffer.Len()),
+		beep.Loop(-1, buffer.Streamer(0, buffer.Len())),
+	)
+	s := &Source{engine: a.bus.engine, streamer: loop}
+	a.bus.Play(s)
+	return s
+}
+
+// Reload decodes every sound again, for when their files change. Sources
+// already playing carry on with the old sound.
+func (a *Ambience) Reload() error {
+	sounds, err := a.load()
+	if err != nil {
+		return err
+	}
+	a.bus.engine.lock()
+	defer a.bus.engine.unlock()
+	a.sounds = sounds
+	return nil
+}
+
+// Source is a sound playing from somewhere around the listener.
+type Source struct {
+	engine   *Engine
+	streamer beep.Streamer
+
+	// gain and pan are where the source is heard from, gliding towards
+	// targetGain and targetPan. Pan goes from -1 for the left to 1 for the
+	// right. They are guarded by the engine lock.
+	gain, targetGain float64
+	pan, targetPan   float64
+	stopped          bool
+}
+
+// Place moves the source to (x, y) from the listener, with x to the right
+// and y up.
+func (s *Source) Place(x, y float64) {
+	gain := 0.0
+	if distance := math.Hypot(x, y); distance < HearingDistance {
+		// Falling off with the square of the distance sounds natural
+		// without losing far sources entirely.
+		gain = math.Pow(1-distance/HearingDistance, 2)
+	}
+	pan := math.Max(-1, math.Min(1, x/panDistance))
+
+	s.engine.lock()
+	defer s.engine.unlock()
+	s.targetGain = gain
+	s.targetPan = pan
+}
+
+// Stop fades the source out and stops it.
+func (s *Source) Stop() {
+	s.engine.lock()
+	defer s.engine.unlock()
+	s.stopped = true
+	s.targetGain = 0
+}
+
+func (s *Source) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.stopped && s.gain < 1e-4 {
+		return 0, false
+	}
+	n, ok = s.streamer.Stream(samples)
+	glide := 1 - math.Exp(-1/float64(SampleRate.N(placeGlide)))
+	for i := range samples[:n] {
+		s.gain += glide * (s.targetGain - s.gain)
+		s.pan += glide * (s.targetPan - s.pan)
+		// The sound is mixed down to mono and panned at equal power, so
+		// it is as loud wherever it is.
+		mono := (samples[i][0] + samples[i][1]) / 2 * s.gain
+		angle := (s.pan + 1) * math.Pi / 4
+		samples[i][0] = mono * math.Cos(angle)
+		samples[i][1] = mono * math.Sin(angle)
+	}
+	return n, ok
+}
+
+func (s *Source) Err() error { return s.streamer.Err() }
@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSourcePlace(t *testing.T) {
+	rec := NewRecorder()
+	engine := NewEngine(rec)
+	ambience, err := LoadAmbience(fstest.MapFS{"hum.wav": wavFile(time.Second, 0.5)}, engine.SFX,
+		map[string]string{"hum": "hum.wav"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := ambience.Start("hum")
+
+	for _, test := range []struct {
+		name        string
+		x, y        float64
+		left, right float64
+	}{
+		{"unplaced", 0, 0, 0, 0},
+		{"on the listener", 0, 0, 0.5 * math.Sqrt2 / 2, 0.5 * math.Sqrt2 / 2},
+		{"out of hearing", 0, -HearingDistance, 0, 0},
+		{"far left", -panDistance, 0, 0.5 / 9, 0},
+		{"right", HearingDistance / 3, 0, 0.5 * 4 / 9 * math.Cos(3*math.Pi/8), 0.5 * 4 / 9 * math.Sin(3*math.Pi/8)},
+	} {
+		if test.name != "unplaced" {
+			source.Place(test.x, test.y)
+		}
+		samples := rec.Record(10 * placeGlide)
+		left, right := samples[len(samples)-1][0], samples[len(samples)-1][1]
+		if math.Abs(left-test.left) > 0.01 || math.Abs(right-test.right) > 0.01 {
+			t.Errorf("%s: got %.3f, %.3f, want %.3f, %.3f", test.name, left, right, test.left, test.right)
+		}
+	}
+
+	source.Stop()
+	rec.Record(10 * placeGlide)
+	if n := engine.SFX.mixer.Len(); n != 0 {
+		t.Errorf("%d sources still playing after being stopped", n)
+	}
+}
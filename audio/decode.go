@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/flac"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
+	"github.com/faiface/beep/wav"
+	"github.com/pkg/errors"
+)
+
+// decoders decode sound files by their extension.
+var decoders = map[string]func(io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error){
+	".mp3":  mp3.Decode,
+	".ogg":  vorbis.Decode,
+	".wav":  wav.Decode,
+	".flac": flac.Decode,
+}
+
+// IsSound reports whether a file is in a format the game can play: MP3, OGG
+// Vorbis, WAV or FLAC.
+func IsSound(name string) bool {
+	_, ok := decoders[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+// Decode opens a sound file from fsys in any of the formats IsSound accepts.
+// Closing the streamer closes the file.
+func Decode(fsys fs.FS, name string) (beep.StreamSeekCloser, beep.Format, error) {
+	decoder, ok := decoders[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return nil, beep.Format{}, errors.Errorf("%s: not an MP3, OGG, WAV or FLAC file", name)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	streamer, format, err := decoder(f)
+	if err != nil {
+		f.Close()
+		return nil, beep.Format{}, errors.Wrap(err, name)
+	}
+	return streamer, format, nil
+}
@@ -0,0 +1,31 @@
+// Package device plays the sound the audio package mixes through the
+// computer's sound device.
+package device
+
+import (
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+	"storj.io/snoboard/audio"
+)
+
+// bufferDuration is how much sound the speaker buffers. Longer is less
+// likely to crackle, shorter responds to the game sooner.
+const bufferDuration = time.Second / 20
+
+// Speaker is the audio.Output of the sound device.
+type Speaker struct{}
+
+// Open sets up the sound device to play at audio.SampleRate. It should only
+// be called once.
+func Open() (Speaker, error) {
+	if err := speaker.Init(audio.SampleRate, audio.SampleRate.N(bufferDuration)); err != nil {
+		return Speaker{}, err
+	}
+	return Speaker{}, nil
+}
+
+func (Speaker) Play(s beep.Streamer) { speaker.Play(s) }
+func (Speaker) Lock()                { speaker.Lock() }
+func (Speaker) Unlock()              { speaker.Unlock() }
@@ -0,0 +1,158 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// How the background music reacts to the game.
+const (
+	// tempoStep is how much faster the music plays each level, up to
+	// maxTempo times its normal speed. Pitch rises with it.
+	tempoStep = 0.04
+	maxTempo  = 1.3
+	// tempoGlide is how long the music takes to reach a new tempo.
+	tempoGlide = 2 * time.Second
+
+	// sweepDown and sweepUp are how long the filter takes to close on a
+	// jump and open again, and sweepCutoff is how far it closes, in Hz.
+	sweepDown   = 150 * time.Millisecond
+	sweepUp     = 600 * time.Millisecond
+	sweepCutoff = 700.0
+	// openCutoff is a cutoff high enough to leave the music untouched.
+	openCutoff = 20000.0
+
+	// duckGain is how quiet the music gets under the crash, reached after
+	// duckAttack and recovered from over duckRelease.
+	duckGain    = 0.25
+	duckAttack  = 100 * time.Millisecond
+	duckRelease = 1500 * time.Millisecond
+)
+
+// dynamics changes the speed, tone and volume of a stream as the game
+// goes on. Every field is guarded by the engine lock.
+type dynamics struct {
+	tempo *beep.Resampler
+
+	// targetTempo is the speed the tempo is gliding to.
+	targetTempo float64
+
+	// sweep counts samples since the last jump, or is negative when there
+	// is no sweep going.
+	sweep int
+	// filter is the last output of the low-pass filter, per channel.
+	filter [2]float64
+
+	// duck counts samples since the music was ducked, or is negative when
+	// it isn't, and duckHold is how many samples it stays ducked for.
+	duck     int
+	duckHold int
+}
+
+func newDynamics(s beep.Streamer) *dynamics {
+	return &dynamics{
+		tempo:       beep.ResampleRatio(resampleQuality, 1, s),
+		targetTempo: 1,
+		sweep:       -1,
+		duck:        -1,
+	}
+}
+
+// setStreamer swaps the stream being played, keeping the tempo.
+func (d *dynamics) setStreamer(s beep.Streamer) {
+	ratio := d.tempo.Ratio()
+	d.tempo = beep.ResampleRatio(resampleQuality, ratio, s)
+}
+
+func (d *dynamics) Stream(samples [][2]float64) (n int, ok bool) {
+	d.glideTempo(len(samples))
+	n, ok = d.tempo.Stream(samples)
+	for i := range samples[:n] {
+		if d.sweep == 0 {
+			// Start the filter from the music as it is, not where the
+			// last sweep left it, to avoid a click.
+			d.filter = samples[i]
+		}
+		if d.sweep >= 0 {
+			d.lowPass(&samples[i], sweepCutoffAt(d.sweep))
+			d.sweep++
+			if d.sweep > SampleRate.N(sweepDown+sweepUp) {
+				d.sweep = -1
+			}
+		}
+		if d.duck >= 0 {
+			gain := duckGainAt(d.duck, d.duckHold)
+			samples[i][0] *= gain
+			samples[i][1] *= gain
+			d.duck++
+			if d.duck > d.duckHold+SampleRate.N(duckRelease) {
+				d.duck = -1
+			}
+		}
+	}
+	return n, ok
+}
+
+func (d *dynamics) Err() error { return d.tempo.Err() }
+
+// glideTempo moves the tempo towards its target by as much as it moves in
+// the given number of samples.
+func (d *dynamics) glideTempo(samples int) {
+	ratio := d.tempo.Ratio()
+	if ratio == d.targetTempo {
+		return
+	}
+	step := (maxTempo - 1) * float64(samples) / float64(SampleRate.N(tempoGlide))
+	if math.Abs(d.targetTempo-ratio) <= step {
+		ratio = d.targetTempo
+	} else if d.targetTempo > ratio {
+		ratio += step
+	} else {
+		ratio -= step
+	}
+	d.tempo.SetRatio(ratio)
+}
+
+// lowPass runs a sample through a one pole low-pass filter.
+func (d *dynamics) lowPass(sample *[2]float64, cutoff float64) {
+	a := 1 - math.Exp(-2*math.Pi*cutoff/float64(SampleRate))
+	for c := range sample {
+		d.filter[c] += a * (sample[c] - d.filter[c])
+		sample[c] = d.filter[c]
+	}
+}
+
+// sweepCutoffAt returns the cutoff of the filter the given number of samples
+// into a sweep. It falls to sweepCutoff and rises back, both exponentially,
+// which sounds even.
+func sweepCutoffAt(sample int) float64 {
+	down, up := SampleRate.N(sweepDown), SampleRate.N(sweepUp)
+	var t float64
+	if sample < down {
+		t = float64(sample) / float64(down)
+	} else {
+		t = 1 - float64(sample-down)/float64(up)
+	}
+	return openCutoff * math.Pow(sweepCutoff/openCutoff, math.Max(0, t))
+}
+
+// duckGainAt returns the gain of the music the given number of samples after
+// it was ducked for hold samples.
+func duckGainAt(sample, hold int) float64 {
+	attack, release := SampleRate.N(duckAttack), SampleRate.N(duckRelease)
+	switch {
+	case sample < attack:
+		return 1 - (1-duckGain)*float64(sample)/float64(attack)
+	case sample < hold:
+		return duckGain
+	default:
+		return duckGain + (1-duckGain)*math.Min(1, float64(sample-hold)/float64(release))
+	}
+}
+
+// levelTempo is how fast the music plays at a level.
+func levelTempo(level float64) float64 {
+	return math.Min(maxTempo, 1+tempoStep*level)
+}
@@ -0,0 +1,153 @@
+package audio
+
+import (
+	"github.com/faiface/beep"
+)
+
+// SampleRate is the rate every sound is mixed and played at. Files recorded
+// at other rates are resampled to it.
+const SampleRate beep.SampleRate = 44100
+
+// resampleQuality is the quality sounds are resampled at, good enough for
+// doing it as they play.
+const resampleQuality = 4
+
+// Engine mixes every sound the game makes and plays it through an Output.
+// Sounds are played on a bus, either Music or SFX, which are mixed into
+// Master. Each bus has its own volume.
+type Engine struct {
+	Master *Bus
+	Music  *Bus
+	SFX    *Bus
+
+	out  Output
+	ctrl *beep.Ctrl
+}
+
+// NewEngine starts playing silence through out, ready for sounds to be
+// played. There should only ever be one engine for an output.
+func NewEngine(out Output) *Engine {
+	e := &Engine{out: out}
+	e.Master = newBus(e)
+	e.Music = newBus(e)
+	e.SFX = newBus(e)
+	e.Master.mixer.Add(e.Music, e.SFX)
+	e.ctrl = &beep.Ctrl{Streamer: e.Master}
+	out.Play(e.ctrl)
+	return e
+}
+
+// lock stops the output pulling sound, so that streamers being played can
+// be changed.
+func (e *Engine) lock()   { e.out.Lock() }
+func (e *Engine) unlock() { e.out.Unlock() }
+
+// Pause freezes every sound where it is.
+func (e *Engine) Pause() { e.setPaused(true) }
+
+// Resume carries on playing every sound from where Pause left it.
+func (e *Engine) Resume() { e.setPaused(false) }
+
+func (e *Engine) setPaused(paused bool) {
+	e.lock()
+	defer e.unlock()
+	e.ctrl.Paused = paused
+}
+
+// Close stops every sound. The output plays silence from then on.
+func (e *Engine) Close() {
+	e.lock()
+	defer e.unlock()
+	e.Master.mixer.Clear()
+	e.Music.mixer.Clear()
+	e.SFX.mixer.Clear()
+}
+
+// Bus is a group of sounds mixed together at one volume.
+type Bus struct {
+	engine *Engine
+	mixer  beep.Mixer
+	volume float64
+	muted  bool
+}
+
+func newBus(e *Engine) *Bus {
+	return &Bus{engine: e, volume: 1}
+}
+
+// Play starts playing sounds on the bus. They must stream at SampleRate.
+func (b *Bus) Play(s ...beep.Streamer) {
+	b.engine.lock()
+	defer b.engine.unlock()
+	b.mixer.Add(s...)
+}
+
+// Clear stops every sound on the bus.
+func (b *Bus) Clear() {
+	b.engine.lock()
+	defer b.engine.unlock()
+	b.mixer.Clear()
+}
+
+// Volume returns the volume of the bus, from 0 for silent to 1 for the
+// sounds as they were recorded.
+func (b *Bus) Volume() float64 {
+	b.engine.lock()
+	defer b.engine.unlock()
+	return b.volume
+}
+
+// SetVolume sets the volume of the bus, clamped between 0 and 1.
+func (b *Bus) SetVolume(volume float64) {
+	if volume < 0 {
+		volume = 0
+	}
+	if volume > 1 {
+		volume = 1
+	}
+	b.engine.lock()
+	defer b.engine.unlock()
+	b.volume = volume
+}
+
+// Muted reports whether the bus is muted.
+func (b *Bus) Muted() bool {
+	b.engine.lock()
+	defer b.engine.unlock()
+	return b.muted
+}
+
+// SetMuted silences the bus, or unmutes it at the volume it had before.
+func (b *Bus) SetMuted(muted bool) {
+	b.engine.lock()
+	defer b.engine.unlock()
+	b.muted = muted
+}
+
+// Stream mixes the sounds on the bus and applies its volume. It is called by
+// the output, which holds the lock.
+func (b *Bus) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = b.mixer.Stream(samples)
+	gain := b.volume
+	if b.muted {
+		gain = 0
+	}
+	if gain != 1 {
+		for i := range samples[:n] {
+			samples[i][0] *= gain
+			samples[i][1] *= gain
+		}
+	}
+	return n, ok
+}
+
+// Err always returns nil, since the mixer drops sounds that fail.
+func (b *Bus) Err() error { return nil }
+
+// resample converts a sound to SampleRate if it isn't already.
+func resample(s beep.Streamer, format beep.Format) beep.Streamer {
+	if format.SampleRate == SampleRate {
+		return s
+	}
+	return beep.Resample(resampleQuality, format.SampleRate, SampleRate, s)
+}
@@ -1,84 +1,176 @@
 package audio
 
 import (
-	"log"
-	"os"
+	"io/fs"
 	"time"
 
 	"github.com/faiface/beep"
-	"github.com/faiface/beep/effects"
-	"github.com/faiface/beep/mp3"
-	"github.com/faiface/beep/speaker"
+	"github.com/pkg/errors"
 )
 
+// Files are the paths of the sounds Music plays.
+type Files struct {
+	Dead string
+	// Playlist is the background music: sound files, and directories
+	// whose sound files are all played.
+	Playlist []string
+	// Ambient are the loops, by name, that sources in the world play.
+	Ambient map[string]string
+}
+
+// crash is the part of the dead sound played when the player crashes.
+const crash = "crash"
+
+// Music plays the game's sounds through an Engine.
 type Music struct {
-	deadStreamer beep.StreamSeekCloser
+	*Engine
+	// Ambience plays the sounds of things around the player.
+	Ambience *Ambience
+
+	fsys    fs.FS
+	files   Files
+	effects *Effects
+
+	playlist       *playlist
+	backgroundCtrl *beep.Ctrl
+	dynamics       *dynamics
 }
 
-func NewMusic() *Music {
-	f, err := os.Open("./dead.mp3")
+// NewMusic starts an audio engine playing through out and loads the sounds
+// in files from fsys.
+func NewMusic(out Output, fsys fs.FS, files Files, options PlaylistOptions) (*Music, error) {
+	engine := NewEngine(out)
+	music := &Music{
+		Engine:   engine,
+		fsys:     fsys,
+		files:    files,
+		dynamics: newDynamics(beep.Silence(-1)),
+	}
+	music.backgroundCtrl = &beep.Ctrl{Streamer: music.dynamics}
+	var err error
+	music.effects, err = LoadEffects(fsys, engine.SFX, map[string]Effect{
+		crash: {Path: files.Dead, Start: 2279 * time.Millisecond, Polyphony: 1},
+	})
 	if err != nil {
-		log.Fatal(err)
+		engine.Close()
+		return nil, err
 	}
-
-	streamer, _, err := mp3.Decode(f)
+	music.Ambience, err = LoadAmbience(fsys, engine.SFX, files.Ambient)
 	if err != nil {
-		log.Fatal(err)
+		engine.Close()
+		return nil, err
 	}
-	//defer streamer.Close()
-
-	ctrl := &beep.Ctrl{Streamer: beep.Loop(-1, streamer), Paused: false}
-	volume := &effects.Volume{
-		Streamer: ctrl,
-		Base:     2,
-		Volume:   0.0,
-		Silent:   false,
+	if err := music.reloadPlaylist(options); err != nil {
+		engine.Close()
+		return nil, err
 	}
-	speaker.Play(volume)
+	return music, nil
+}
 
-	return &Music{
-		deadStreamer: streamer,
-	}
+// PlayBackgroundMusic starts the playlist on the music bus.
+func (music *Music) PlayBackgroundMusic() {
+	music.Music.Play(music.backgroundCtrl)
 }
 
-func (music Music) PlayBackgroundMusic() {
-	f, err := os.Open("./danger_zone.mp3")
-	if err != nil {
-		log.Fatal(err)
+// LevelUp speeds the music up, and raises its pitch, to suit the level.
+func (music *Music) LevelUp(level float64) {
+	music.lock()
+	defer music.unlock()
+	music.dynamics.targetTempo = levelTempo(level)
+}
+
+// Jump sweeps a filter over the music, muffling it as the player leaves the
+// ground.
+func (music *Music) Jump() {
+	music.lock()
+	defer music.unlock()
+	music.dynamics.sweep = 0
+}
+
+// PlayDeadSound plays the crash on the sound effects bus, unless it is
+// already playing, and ducks the music under it.
+func (music *Music) PlayDeadSound() {
+	if !music.effects.Play(crash) {
+		return
 	}
+	hold := SampleRate.N(music.effects.Length(crash))
+	music.lock()
+	defer music.unlock()
+	music.dynamics.duck = 0
+	music.dynamics.duckHold = hold
+}
 
-	streamer, format, err := mp3.Decode(f)
-	if err != nil {
-		log.Fatal(err)
+// Restart brings the music back to how it was at the start of a run.
+func (music *Music) Restart() {
+	music.lock()
+	defer music.unlock()
+	music.dynamics.targetTempo = levelTempo(0)
+	music.dynamics.duck = -1
+}
+
+// SkipTrack fades to the next track in the playlist.
+func (music *Music) SkipTrack() {
+	music.lock()
+	defer music.unlock()
+	music.playlist.skip()
+}
+
+// Track returns the path of the track playing.
+func (music *Music) Track() string {
+	music.lock()
+	defer music.unlock()
+	return music.playlist.track()
+}
+
+// Reload decodes the sound files again and swaps them in without stopping
+// playback, so changed sounds can be heard straight away. The playlist
+// starts again, with any tracks added to its directories.
+func (music *Music) Reload() error {
+	if err := music.effects.Reload(); err != nil {
+		return err
 	}
-	defer streamer.Close()
-
-	ctrl := &beep.Ctrl{Streamer: beep.Loop(-1, streamer), Paused: false}
-	volume := &effects.Volume{
-		Streamer: ctrl,
-		Base:     2,
-		Volume:   +0.0,
-		Silent:   false,
+	if err := music.Ambience.Reload(); err != nil {
+		return err
 	}
-	speaker.Play(volume)
-
-	_ = speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
+	return music.reloadPlaylist(music.PlaylistOptions())
+}
 
-	//speaker.Play(streamer)
+// PlaylistOptions returns how the playlist is played.
+func (music *Music) PlaylistOptions() PlaylistOptions {
+	music.lock()
+	defer music.unlock()
+	return music.playlist.options
+}
 
-	done := make(chan bool)
-	speaker.Play(beep.Seq(streamer, beep.Callback(func() {
-		done <- true
-	})))
-	<-done
+// SetPlaylistOptions changes how the playlist is played, from the next track
+// on.
+func (music *Music) SetPlaylistOptions(options PlaylistOptions) {
+	music.lock()
+	defer music.unlock()
+	music.playlist.options = options
 }
 
-func (music Music) PlayDeadSound() {
-	_ = music.deadStreamer.Seek(100500.0)
+// reloadPlaylist finds and opens the playlist's tracks and swaps it in.
+func (music *Music) reloadPlaylist(options PlaylistOptions) error {
+	playlist, err := newPlaylist(music.fsys, music.files.Playlist, options, music.out)
+	if err != nil {
+		return errors.Wrap(err, "error loading playlist")
+	}
+
+	music.lock()
+	defer music.unlock()
+	if music.playlist != nil {
+		music.playlist.Close()
+	}
+	music.playlist = playlist
+	music.dynamics.setStreamer(playlist)
+	return nil
+}
 
-	done := make(chan bool)
-	speaker.Play(beep.Seq(music.deadStreamer, beep.Callback(func() {
-		done <- true
-	})))
-	<-done
+// Close stops every sound and closes the sound files.
+func (music *Music) Close() error {
+	music.Engine.Close()
+	music.lock()
+	defer music.unlock()
+	return music.playlist.Close()
 }
@@ -0,0 +1,109 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// wavFile returns a 16-bit stereo WAV at SampleRate that holds a constant
+// level for d, which is easy to pick out of a mix.
+func wavFile(d time.Duration, level float64) *fstest.MapFile {
+	n := SampleRate.N(d)
+	var buf bytes.Buffer
+	write := func(v interface{}) { _ = binary.Write(&buf, binary.LittleEndian, v) }
+	buf.WriteString("RIFF")
+	write(uint32(36 + n*4))
+	buf.WriteString("WAVEfmt ")
+	write(uint32(16))
+	write(uint16(1)) // PCM
+	write(uint16(2))
+	write(uint32(SampleRate))
+	write(uint32(SampleRate * 4))
+	write(uint16(4))
+	write(uint16(16))
+	buf.WriteString("data")
+	write(uint32(n * 4))
+	sample := int16(level * math.MaxInt16)
+	for i := 0; i < n; i++ {
+		write([2]int16{sample, sample})
+	}
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
+
+// newTestMusic starts music playing into a recorder, with a playlist of one
+// long track at the music level and a dead sound at the dead level.
+func newTestMusic(t *testing.T, music, dead float64) (*Music, *Recorder) {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"music/track.wav": wavFile(10*time.Second, music),
+		"dead.wav":        wavFile(3*time.Second, dead),
+		"hum.wav":         wavFile(time.Second, 0),
+	}
+	rec := NewRecorder()
+	m, err := NewMusic(rec, fsys, Files{
+		Dead:     "dead.wav",
+		Playlist: []string{"music"},
+		Ambient:  map[string]string{"hum": "hum.wav"},
+	}, PlaylistOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.PlayBackgroundMusic()
+	return m, rec
+}
+
+// level returns the level of the left channel at the end of samples.
+func level(samples [][2]float64) float64 {
+	return samples[len(samples)-1][0]
+}
+
+func assertLevel(t *testing.T, what string, samples [][2]float64, want float64) {
+	t.Helper()
+	if got := level(samples); math.Abs(got-want) > 0.01 {
+		t.Errorf("%s: level is %.3f, want %.3f", what, got, want)
+	}
+}
+
+func TestMusicVolume(t *testing.T) {
+	music, rec := newTestMusic(t, 0.5, 0)
+	defer music.Close()
+	assertLevel(t, "full volume", rec.Record(100*time.Millisecond), 0.5)
+
+	music.Music.SetVolume(0.5)
+	music.Master.SetVolume(0.5)
+	assertLevel(t, "half music and master volume", rec.Record(100*time.Millisecond), 0.125)
+
+	music.Master.SetMuted(true)
+	assertLevel(t, "muted", rec.Record(100*time.Millisecond), 0)
+	music.Master.SetMuted(false)
+	assertLevel(t, "unmuted", rec.Record(100*time.Millisecond), 0.125)
+}
+
+func TestPlayDeadSound(t *testing.T) {
+	music, rec := newTestMusic(t, 0, 0.4)
+	defer music.Close()
+	assertLevel(t, "before dying", rec.Record(100*time.Millisecond), 0)
+
+	music.PlayDeadSound()
+	// Dying again while the crash plays mustn't play it twice as loud.
+	music.PlayDeadSound()
+	assertLevel(t, "crash", rec.Record(100*time.Millisecond), 0.4)
+
+	rec.Record(music.effects.Length(crash))
+	assertLevel(t, "after the crash", rec.Record(100*time.Millisecond), 0)
+}
+
+func TestPlayDeadSoundDucksMusic(t *testing.T) {
+	music, rec := newTestMusic(t, 0.5, 0)
+	defer music.Close()
+
+	music.PlayDeadSound()
+	assertLevel(t, "ducked", rec.Record(duckAttack+50*time.Millisecond), 0.5*duckGain)
+
+	rec.Record(music.effects.Length(crash) + duckRelease)
+	assertLevel(t, "released", rec.Record(100*time.Millisecond), 0.5)
+}
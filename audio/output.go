@@ -0,0 +1,99 @@
+package audio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// Output is where an Engine sends the sound it mixes, normally the speaker.
+// It pulls sound from the streamer it plays on its own schedule, from
+// another goroutine, and never while it is locked.
+type Output interface {
+	// Play starts pulling sound from s, at SampleRate.
+	Play(s beep.Streamer)
+	// Lock stops the output pulling sound until Unlock, so that the
+	// streamers it plays can be changed.
+	Lock()
+	Unlock()
+}
+
+// nullInterval is how often Null pulls sound, about as often as the
+// speaker does.
+const nullInterval = time.Second / 20
+
+// Null is an Output for running without a sound device. It pulls sound as
+// fast as a speaker would and throws it away, so that sounds still finish
+// and are cleared from the mixers.
+type Null struct {
+	mu       sync.Mutex
+	streamer beep.Streamer
+}
+
+func (n *Null) Play(s beep.Streamer) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.streamer == nil {
+		go n.run()
+	}
+	n.streamer = s
+}
+
+func (n *Null) Lock()   { n.mu.Lock() }
+func (n *Null) Unlock() { n.mu.Unlock() }
+
+// run streams and discards nullInterval of sound every nullInterval.
+func (n *Null) run() {
+	samples := make([][2]float64, SampleRate.N(nullInterval))
+	ticker := time.NewTicker(nullInterval)
+	for range ticker.C {
+		n.mu.Lock()
+		n.streamer.Stream(samples)
+		n.mu.Unlock()
+	}
+}
+
+// Recorder is an Output that keeps the sound it plays in memory, so that
+// what the game plays can be listened to without a sound device. It only
+// plays when Record is called.
+type Recorder struct {
+	mu       sync.Mutex
+	streamer beep.Streamer
+	samples  [][2]float64
+}
+
+// NewRecorder returns a Recorder playing silence.
+func NewRecorder() *Recorder {
+	return &Recorder{streamer: beep.Silence(-1)}
+}
+
+func (r *Recorder) Play(s beep.Streamer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.streamer = s
+}
+
+func (r *Recorder) Lock()   { r.mu.Lock() }
+func (r *Recorder) Unlock() { r.mu.Unlock() }
+
+// Record plays d of sound, as the speaker would, and returns it. Anything
+// after the streamer being played ends is silence.
+func (r *Recorder) Record(d time.Duration) [][2]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	samples := make([][2]float64, SampleRate.N(d))
+	n, _ := r.streamer.Stream(samples)
+	for i := n; i < len(samples); i++ {
+		samples[i] = [2]float64{}
+	}
+	r.samples = append(r.samples, samples...)
+	return samples
+}
+
+// Samples returns everything recorded so far.
+func (r *Recorder) Samples() [][2]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.samples
+}
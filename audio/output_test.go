@@ -0,0 +1,27 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+func TestNullFinishesSounds(t *testing.T) {
+	e := NewEngine(&Null{})
+	defer e.Close()
+	e.SFX.Play(beep.Silence(SampleRate.N(time.Second / 10)))
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		e.lock()
+		playing := e.SFX.mixer.Len()
+		e.unlock()
+		if playing == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a sound played through Null never finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
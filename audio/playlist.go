@@ -0,0 +1,342 @@
+package audio
+
+import (
+	"io/fs"
+	"math"
+	"math/rand"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/pkg/errors"
+)
+
+// PlaylistOptions are how a playlist orders its tracks and moves between
+// them.
+type PlaylistOptions struct {
+	// Shuffle plays the tracks in a random order, which is new each time
+	// round. Otherwise they play in the order given.
+	Shuffle bool
+	// Crossfade is how long each track fades into the next. Zero cuts
+	// straight from one to the next.
+	Crossfade time.Duration
+}
+
+// playlistChunk is how many samples a playlist streams between checks for
+// whether to start fading to the next track.
+const playlistChunk = 512
+
+// playlist streams its tracks one after another, forever, crossfading
+// between them. Decoding a track takes too long to do while the output
+// waits for sound, so the next track is opened by a loader goroutine as
+// soon as one starts, and handed over under the engine lock. The fields
+// from options on are guarded by that lock.
+type playlist struct {
+	fsys   fs.FS
+	tracks []string
+	lock   sync.Locker
+
+	// rand, order and next belong to whichever goroutine is loading. order
+	// is the order tracks are played in this time round, and next is the
+	// position in it of the track to load next.
+	rand  *rand.Rand
+	order []int
+	next  int
+
+	options  PlaylistOptions
+	current  *track
+	incoming *track
+	// prefetched is the track to play next once it has been opened, and
+	// loadErr is set instead if none of the tracks could be. loading is set
+	// while the loader runs.
+	prefetched *track
+	loadErr    error
+	loading    bool
+	// skipping is set when the current track should fade out as soon as the
+	// next one is ready.
+	skipping bool
+	closed   bool
+	// fade counts samples into the crossfade to incoming, which lasts
+	// fadeLength samples.
+	fade       int
+	fadeLength int
+
+	buf [][2]float64
+}
+
+// track is a playing track, decoded and resampled to SampleRate.
+type track struct {
+	name     string
+	file     beep.StreamSeekCloser
+	format   beep.Format
+	streamer beep.Streamer
+}
+
+// newPlaylist finds the tracks at paths, which are sound files or
+// directories of them, and opens the first. The rest are opened under lock,
+// which must be the engine lock of the output the playlist is streamed to.
+func newPlaylist(fsys fs.FS, paths []string, options PlaylistOptions, lock sync.Locker) (*playlist, error) {
+	tracks, err := findTracks(fsys, paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, errors.New("playlist has no tracks")
+	}
+	p := &playlist{
+		fsys:    fsys,
+		tracks:  tracks,
+		lock:    lock,
+		options: options,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		buf:     make([][2]float64, playlistChunk),
+	}
+	p.current, err = p.open(options.Shuffle)
+	if err != nil {
+		return nil, err
+	}
+	p.prefetch()
+	return p, nil
+}
+
+// findTracks lists the sound files at paths, in order, with the files in a
+// directory sorted by name.
+func findTracks(fsys fs.FS, paths []string) ([]string, error) {
+	var tracks []string
+	for _, name := range paths {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			tracks = append(tracks, name)
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		for _, entry := range entries {
+			if !entry.IsDir() && IsSound(entry.Name()) {
+				found = append(found, path.Join(name, entry.Name()))
+			}
+		}
+		sort.Strings(found)
+		tracks = append(tracks, found...)
+	}
+	return tracks, nil
+}
+
+// prefetch starts the loader opening the next track, unless it is already
+// loading or has one ready.
+func (p *playlist) prefetch() {
+	if p.loading || p.prefetched != nil || p.closed {
+		return
+	}
+	p.loading = true
+	go p.load(p.options.Shuffle)
+}
+
+// load opens the next track without holding the engine lock, then hands it
+// over.
+func (p *playlist) load(shuffle bool) {
+	t, err := p.open(shuffle)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.loading = false
+	if p.closed {
+		if t != nil {
+			t.file.Close()
+		}
+		return
+	}
+	p.prefetched, p.loadErr = t, err
+}
+
+// open decodes the next track in the order, skipping any that fail. It
+// returns the last error if none of them decode.
+func (p *playlist) open(shuffle bool) (*track, error) {
+	var err error
+	for range p.tracks {
+		name := p.tracks[p.nextIndex(shuffle)]
+		var t *track
+		if t, err = openTrack(p.fsys, name); err == nil {
+			return t, nil
+		}
+	}
+	return nil, err
+}
+
+// nextIndex moves on through the order, starting a new one after the last
+// track.
+func (p *playlist) nextIndex(shuffle bool) int {
+	if p.next >= len(p.order) {
+		last := -1
+		if len(p.order) > 0 {
+			last = p.order[len(p.order)-1]
+		}
+		p.order = make([]int, len(p.tracks))
+		for i := range p.order {
+			p.order[i] = i
+		}
+		if shuffle {
+			p.rand.Shuffle(len(p.order), func(i, j int) {
+				p.order[i], p.order[j] = p.order[j], p.order[i]
+			})
+			// Don't play the same track twice in a row.
+			if len(p.order) > 1 && p.order[0] == last {
+				p.order[0], p.order[1] = p.order[1], p.order[0]
+			}
+		}
+		p.next = 0
+	}
+	i := p.order[p.next]
+	p.next++
+	return i
+}
+
+func openTrack(fsys fs.FS, name string) (*track, error) {
+	file, format, err := Decode(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return &track{
+		name:     name,
+		file:     file,
+		format:   format,
+		streamer: resample(file, format),
+	}, nil
+}
+
+// remaining is how many samples are left in the track, at SampleRate.
+func (t *track) remaining() int {
+	left := t.format.SampleRate.D(t.file.Len() - t.file.Position())
+	return SampleRate.N(left)
+}
+
+// fill streams the track into samples, padding with silence after it ends,
+// and returns how many samples came from the track.
+func (t *track) fill(samples [][2]float64) int {
+	n, _ := t.streamer.Stream(samples)
+	for i := n; i < len(samples); i++ {
+		samples[i] = [2]float64{}
+	}
+	return n
+}
+
+// skip starts fading to the next track now, or as soon as it is ready.
+func (p *playlist) skip() {
+	if p.incoming != nil {
+		return
+	}
+	p.skipping = true
+	p.startFade(SampleRate.N(p.options.Crossfade))
+}
+
+// startFade starts fading to the next track over length samples, if it has
+// been opened. If no other track can be, the current one starts again
+// instead.
+func (p *playlist) startFade(length int) {
+	if p.prefetched == nil {
+		if p.loadErr != nil {
+			p.loadErr = nil
+			p.skipping = false
+			_ = p.current.file.Seek(0)
+			p.prefetch()
+		}
+		return
+	}
+	p.incoming = p.prefetched
+	p.prefetched = nil
+	p.skipping = false
+	p.fade = 0
+	p.fadeLength = length
+}
+
+// finishFade makes the incoming track the current one and starts opening
+// the one after.
+func (p *playlist) finishFade() {
+	p.current.file.Close()
+	p.current = p.incoming
+	p.incoming = nil
+	p.prefetch()
+}
+
+func (p *playlist) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		chunk := samples[n:]
+		if len(chunk) > playlistChunk {
+			chunk = chunk[:playlistChunk]
+		}
+		if p.incoming == nil {
+			crossfade := SampleRate.N(p.options.Crossfade)
+			if remaining := p.current.remaining(); remaining <= crossfade {
+				p.startFade(remaining)
+			} else if p.skipping {
+				p.startFade(crossfade)
+			}
+		}
+
+		played := p.current.fill(chunk)
+		if p.incoming == nil {
+			if played == 0 {
+				// The track ended sooner than its length said it would.
+				p.startFade(0)
+				if p.incoming == nil {
+					// Nothing will play, so play silence.
+					for i := range samples[n:] {
+						samples[n+i] = [2]float64{}
+					}
+					return len(samples), true
+				}
+				p.finishFade()
+			}
+			n += played
+			continue
+		}
+
+		p.incoming.fill(p.buf[:len(chunk)])
+		for i := range chunk {
+			// An equal power crossfade keeps the loudness steady.
+			t := 1.0
+			if p.fade < p.fadeLength {
+				t = float64(p.fade) / float64(p.fadeLength)
+			}
+			out, in := math.Cos(t*math.Pi/2), math.Sin(t*math.Pi/2)
+			chunk[i][0] = chunk[i][0]*out + p.buf[i][0]*in
+			chunk[i][1] = chunk[i][1]*out + p.buf[i][1]*in
+			p.fade++
+		}
+		if p.fade >= p.fadeLength {
+			p.finishFade()
+		}
+		n += len(chunk)
+	}
+	return n, true
+}
+
+func (p *playlist) Err() error { return nil }
+
+// track returns the path of the track playing, or fading in.
+func (p *playlist) track() string {
+	if p.incoming != nil {
+		return p.incoming.name
+	}
+	return p.current.name
+}
+
+// Close closes the tracks' files. A track still being loaded is closed by
+// the loader.
+func (p *playlist) Close() error {
+	p.closed = true
+	for _, t := range []*track{p.incoming, p.prefetched} {
+		if t != nil {
+			t.file.Close()
+		}
+	}
+	return p.current.file.Close()
+}
@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// streamPrefetched streams p under mu, the way an output does, once the
+// next track has been opened.
+func streamPrefetched(p *playlist, mu *sync.Mutex, samples [][2]float64) {
+	for {
+		mu.Lock()
+		if !p.loading {
+			break
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	defer mu.Unlock()
+	p.Stream(samples)
+}
+
+func TestPlaylist(t *testing.T) {
+	fsys := fstest.MapFS{
+		"music/a.wav":   wavFile(time.Second, 0.2),
+		"music/b.flac":  {Data: []byte("not a FLAC file")},
+		"music/c.wav":   wavFile(time.Second, 0.6),
+		"music/notes":   {Data: []byte("not a track")},
+		"extra/end.wav": wavFile(time.Second, 0.4),
+	}
+	var mu sync.Mutex
+	p, err := newPlaylist(fsys, []string{"music", "extra/end.wav"}, PlaylistOptions{}, &mu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		p.Close()
+	}()
+
+	// The broken track is skipped, and the playlist goes round again.
+	streamPrefetched(p, &mu, make([][2]float64, SampleRate.N(time.Second/2)))
+	samples := make([][2]float64, SampleRate.N(time.Second))
+	for i, want := range []struct {
+		track string
+		level float64
+	}{
+		{"music/c.wav", 0.6},
+		{"extra/end.wav", 0.4},
+		{"music/a.wav", 0.2},
+	} {
+		streamPrefetched(p, &mu, samples)
+		if track := p.track(); track != want.track {
+			t.Errorf("%d: playing %s, want %s", i, track, want.track)
+		}
+		assertLevel(t, want.track, samples, want.level)
+	}
+
+	// Skipping fades into the next track over the crossfade.
+	streamPrefetched(p, &mu, nil)
+	mu.Lock()
+	p.options.Crossfade = 100 * time.Millisecond
+	p.skip()
+	if track := p.track(); track != "music/c.wav" {
+		t.Errorf("skipped to %s, want music/c.wav", track)
+	}
+	mu.Unlock()
+	streamPrefetched(p, &mu, samples[:SampleRate.N(100*time.Millisecond)/2])
+	if got := level(samples[:SampleRate.N(100*time.Millisecond)/2]); got <= 0.2 || got >= 0.6 {
+		t.Errorf("level halfway through the crossfade is %.3f, want it between the tracks", got)
+	}
+	streamPrefetched(p, &mu, samples[:len(samples)/2])
+	assertLevel(t, "after the crossfade", samples[:len(samples)/2], 0.6)
+}
+
+func TestPlaylistSkipBeforePrefetch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.wav": wavFile(time.Second, 0.2),
+		"b.wav": wavFile(time.Second, 0.6),
+	}
+	var mu sync.Mutex
+	// Holding the lock keeps the loader from handing the next track over.
+	// Failures are only reported once it is let go, so that the deferred
+	// Close can take it.
+	mu.Lock()
+	p, err := newPlaylist(fsys, []string{"a.wav", "b.wav"}, PlaylistOptions{}, &mu)
+	var skipped string
+	if err == nil {
+		p.skip()
+		skipped = p.track()
+	}
+	mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		p.Close()
+	}()
+	if skipped != "a.wav" {
+		t.Errorf("skipped to %s before it was opened", skipped)
+	}
+
+	// The skip happens once the next track is ready.
+	samples := make([][2]float64, SampleRate.N(time.Second/10))
+	streamPrefetched(p, &mu, samples)
+	mu.Lock()
+	track := p.track()
+	mu.Unlock()
+	if track != "b.wav" {
+		t.Errorf("playing %s after skipping, want b.wav", track)
+	}
+	streamPrefetched(p, &mu, samples)
+	assertLevel(t, "after skipping", samples, 0.6)
+}
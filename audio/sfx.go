@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"io/fs"
+	"strconv"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/pkg/errors"
+)
+
+// Effect describes a sound effect: the part of a file it is cut from and how
+// many copies of it can play at once.
+type Effect struct {
+	Path string
+	// Start and End are where the effect is in the file. A zero End is the
+	// end of the file.
+	Start, End time.Duration
+	// Polyphony is how many copies of the effect can play at once. Plays
+	// beyond it are dropped. Zero means one.
+	Polyphony int
+}
+
+// Effects plays sound effects that are decoded ahead of time into memory,
+// so that each play is independent of any others still playing.
+type Effects struct {
+	bus     *Bus
+	fsys    fs.FS
+	effects map[string]*effect
+}
+
+type effect struct {
+	Effect
+	buffer *beep.Buffer
+	// playing counts the copies playing. It is guarded by the engine lock.
+	playing int
+}
+
+// LoadEffects decodes every effect from fsys, to be played on bus.
+func LoadEffects(fsys fs.FS, bus *Bus, effects map[string]Effect) (*Effects, error) {
+	e := &Effects{bus: bus, fsys: fsys, effects: make(map[string]*effect, len(effects))}
+	for name, desc := range effects {
+		buffer, err := bufferEffect(fsys, desc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading sound effect %q", name)
+		}
+		e.effects[name] = &effect{Effect: desc, buffer: buffer}
+	}
+	return e, nil
+}
+
+// bufferEffect decodes the part of a file an effect is cut from, resampled
+// to SampleRate.
+func bufferEffect(fsys fs.FS, desc Effect) (*beep.Buffer, error) {
+	streamer, format, err := Decode(fsys, desc.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer streamer.Close()
+
+	start := format.SampleRate.N(desc.Start)
+	end := streamer.Len()
+	if desc.End > 0 {
+		end = format.SampleRate.N(desc.End)
+	}
+	if start < 0 || end > streamer.Len() || start >= end {
+		return nil, errors.Errorf("cue points %v to %v are outside the %v long sound",
+			desc.Start, desc.End, format.SampleRate.D(streamer.Len()))
+	}
+	if err := streamer.Seek(start); err != nil {
+		return nil, err
+	}
+
+	buffer := beep.NewBuffer(beep.Format{SampleRate: SampleRate, NumChannels: 2, Precision: 2})
+	buffer.Append(resample(beep.Take(end-start, streamer), format))
+	if err := streamer.Err(); err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// Play starts a copy of the named effect. It reports false if the effect is
+// already playing as many times as its polyphony allows.
+func (e *Effects) Play(name string) bool {
+	fx, ok := e.effects[name]
+	if !ok {
+		panic("audio: no sound effect named " + strconv.Quote(name))
+	}
+	limit := fx.Polyphony
+	if limit <= 0 {
+		limit = 1
+	}
+
+	engine := e.bus.engine
+	engine.lock()
+	defer engine.unlock()
+	if fx.playing >= limit {
+		return false
+	}
+	fx.playing++
+	e.bus.mixer.Add(beep.Seq(fx.buffer.Streamer(0, fx.buffer.Len()), beep.Callback(func() {
+		fx.playing--
+	})))
+	return true
+}
+
+// Length returns how long the named effect plays for.
+func (e *Effects) Length(name string) time.Duration {
+	fx, ok := e.effects[name]
+	if !ok {
+		panic("audio: no sound effect named " + strconv.Quote(name))
+	}
+	e.bus.engine.lock()
+	defer e.bus.engine.unlock()
+	return SampleRate.D(fx.buffer.Len())
+}
+
+// Reload decodes every effect again, for when their files change. Copies
+// already playing finish with the old sound.
+func (e *Effects) Reload() error {
+	buffers := make(map[string]*beep.Buffer, len(e.effects))
+	for name, fx := range e.effects {
+		buffer, err := bufferEffect(e.fsys, fx.Effect)
+		if err != nil {
+			return errors.Wrapf(err, "error loading sound effect %q", name)
+		}
+		buffers[name] = buffer
+	}
+
+	engine := e.bus.engine
+	engine.lock()
+	defer engine.unlock()
+	for name, buffer := range buffers {
+		e.effects[name].buffer = buffer
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runCommand runs a subcommand given on the command line instead of the
+// game, and returns the status to exit with.
+func runCommand(args []string) int {
+	switch strings.Join(args, " ") {
+	case "assets check":
+		return checkAssets()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", strings.Join(args, " "))
+		fmt.Fprintln(os.Stderr, "commands:")
+		fmt.Fprintln(os.Stderr, "  assets check    load every asset and report problems with them")
+		return 2
+	}
+}
+
+// checkAssets loads every asset in the manifest without opening a window,
+// and prints what is wrong with them. It fails if anything would stop the
+// game from loading.
+func checkAssets() int {
+	a, _, err := openAssets()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var errs, warnings int
+	for _, p := range a.Check("graphics") {
+		fmt.Println(p)
+		if p.Warning {
+			warnings++
+		} else {
+			errs++
+		}
+	}
+	fmt.Printf("%d assets checked: %d errors, %d warnings\n", len(a.Paths()), errs, warnings)
+	if errs > 0 {
+		return 1
+	}
+	return 0
+}
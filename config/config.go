@@ -0,0 +1,132 @@
+// Package config loads the tuning for the game and its window from a JSON
+// file, so the feel of the game can be changed without recompiling.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"storj.io/snoboard/game"
+)
+
+// Config is the contents of a config file.
+type Config struct {
+	Game    game.Config `json:"game"`
+	Display Display     `json:"display"`
+	Audio   Audio       `json:"audio"`
+}
+
+// Display holds the settings for the window and how the world is drawn.
+type Display struct {
+	// WindowWidth and WindowHeight are the size of the window, before it is
+	// scaled by ContentScale.
+	WindowWidth  float64 `json:"window_width"`
+	WindowHeight float64 `json:"window_height"`
+	// ContentScale is how many screen pixels make up one pixel of the game,
+	// 2 on most Retina and 4K screens. Zero works it out from the monitor.
+	ContentScale float64 `json:"content_scale"`
+	// CameraOffset is how far below the player the camera is centered.
+	CameraOffset float64 `json:"camera_offset"`
+	// BackgroundTileWidth and BackgroundTileHeight are the distances after
+	// which the snow background repeats.
+	BackgroundTileWidth  int `json:"background_tile_width"`
+	BackgroundTileHeight int `json:"background_tile_height"`
+	// StepRate is how many times per second the game world is stepped.
+	StepRate float64 `json:"step_rate"`
+	// MaxStepsPerFrame caps how far the world catches up after a slow frame.
+	MaxStepsPerFrame int `json:"max_steps_per_frame"`
+}
+
+// Audio holds the volume of the game's sounds, each from 0 for silent to 1
+// for as loud as they were recorded, and how the music playlist plays.
+type Audio struct {
+	MasterVolume float64 `json:"master_volume"`
+	MusicVolume  float64 `json:"music_volume"`
+	SFXVolume    float64 `json:"sfx_volume"`
+	// Muted silences the game without losing the volumes.
+	Muted bool `json:"muted"`
+	// Shuffle plays the playlist in a random order.
+	Shuffle bool `json:"shuffle"`
+	// Crossfade is how many seconds each track fades into the next.
+	Crossfade float64 `json:"crossfade"`
+}
+
+// Default returns the config the game ships with.
+func Default() Config {
+	return Config{
+		Game: game.DefaultConfig(),
+		Display: Display{
+			WindowWidth:          1024,
+			WindowHeight:         768,
+			CameraOffset:         200,
+			BackgroundTileWidth:  220,
+			BackgroundTileHeight: 440,
+			StepRate:             120,
+			MaxStepsPerFrame:     8,
+		},
+		Audio: Audio{
+			MasterVolume: 1,
+			MusicVolume:  1,
+			SFXVolume:    1,
+			Crossfade:    3,
+		},
+	}
+}
+
+// Load reads a config file. Settings missing from the file keep their
+// default values.
+func Load(path string) (cfg Config, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrapf(err, "error loading config %q", path)
+		}
+	}()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg = Default()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that every setting has a usable value.
+func (c Config) Validate() error {
+	if err := c.Game.Validate(); err != nil {
+		return err
+	}
+	d := c.Display
+	switch {
+	case d.WindowWidth <= 0 || d.WindowHeight <= 0:
+		return errors.New("window_width and window_height must be positive")
+	case d.ContentScale < 0:
+		return errors.New("content_scale can't be negative")
+	case d.BackgroundTileWidth <= 0 || d.BackgroundTileHeight <= 0:
+		return errors.New("background_tile_width and background_tile_height must be positive")
+	case d.StepRate <= 0:
+		return errors.New("step_rate must be positive")
+	case d.MaxStepsPerFrame <= 0:
+		return errors.New("max_steps_per_frame must be positive")
+	}
+	a := c.Audio
+	for _, volume := range []float64{a.MasterVolume, a.MusicVolume, a.SFXVolume} {
+		if volume < 0 || volume > 1 {
+			return errors.New("master_volume, music_volume and sfx_volume must be between 0 and 1")
+		}
+	}
+	if a.Crossfade < 0 {
+		return errors.New("crossfade can't be negative")
+	}
+	return nil
+}
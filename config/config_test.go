@@ -0,0 +1,81 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	want := Default()
+	want.Game.Speed = 400
+	want.Audio.Muted = true
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"game": {"speed": 400}, "audio": {"muted": true}}`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"not JSON", `{"game":`, "unexpected EOF"},
+		{"unknown section", `{"gmae": {}}`, `unknown field "gmae"`},
+		{"unknown setting", `{"game": {"sped": 400}}`, `unknown field "sped"`},
+		{"speed", `{"game": {"speed": 0}}`, "speed must be positive"},
+		{"jump speed", `{"game": {"jump_speed": -1}}`, "jump_speed must be positive"},
+		{"jump time", `{"game": {"jump_time": 0}}`, "jump_time must be positive"},
+		{"level multiplier", `{"game": {"level_multiplier": 0.9}}`, "level_multiplier must be at least 1"},
+		{"difficulty decay", `{"game": {"difficulty_decay": 0}}`, "difficulty_decay must be positive"},
+		{"level up difficulty", `{"game": {"level_up_difficulty": 1}}`, "level_up_difficulty must be between 0 and 1"},
+		{"spawn width", `{"game": {"spawn_width": 0}}`, "spawn_width must be positive"},
+		{"spawn distance", `{"game": {"spawn_distance": 0}}`, "spawn_distance must be positive"},
+		{"trim distance", `{"game": {"trim_distance": 0}}`, "trim_distance must be positive"},
+		{"window size", `{"display": {"window_height": 0}}`, "window_width and window_height must be positive"},
+		{"content scale", `{"display": {"content_scale": -1}}`, "content_scale can't be negative"},
+		{"background tile", `{"display": {"background_tile_width": 0}}`, "background_tile_width and background_tile_height must be positive"},
+		{"step rate", `{"display": {"step_rate": 0}}`, "step_rate must be positive"},
+		{"max steps", `{"display": {"max_steps_per_frame": 0}}`, "max_steps_per_frame must be positive"},
+		{"volume too low", `{"audio": {"music_volume": -0.1}}`, "must be between 0 and 1"},
+		{"volume too high", `{"audio": {"sfx_volume": 1.1}}`, "must be between 0 and 1"},
+		{"crossfade", `{"audio": {"crossfade": -1}}`, "crossfade can't be negative"},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".json")
+			if err := ioutil.WriteFile(path, []byte(test.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loading a missing file succeeded")
+	}
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Error(err)
+	}
+}
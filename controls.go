@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"storj.io/snoboard/input"
+)
+
+// keyButtons are pixelgl's keys by name.
+var keyButtons = func() map[input.Key]pixelgl.Button {
+	buttons := make(map[input.Key]pixelgl.Button)
+	for b := pixelgl.KeySpace; b <= pixelgl.KeyLast; b++ {
+		if name := b.String(); name != "Invalid" {
+			buttons[input.Key(name)] = b
+		}
+	}
+	return buttons
+}()
+
+func loadBindings(scene *Scene) {
+	scene.bindings = input.Default()
+	path, err := input.DefaultPath()
+	if err != nil {
+		log.Printf("key bindings won't be saved: %v", err)
+		return
+	}
+	bindings, problems, err := input.Load(path)
+	if err != nil {
+		log.Printf("key bindings won't be saved: %v", err)
+		return
+	}
+	for _, p := range problems {
+		log.Printf("%s: %v", path, p)
+	}
+	for _, keys := range bindings {
+		for _, key := range keys {
+			if _, ok := keyButtons[key]; !ok {
+				log.Printf("ignoring unknown key %q in %s", key, path)
+			}
+		}
+	}
+	scene.bindings = bindings
+	scene.bindingsPath = path
+}
+
+func saveBindings(scene *Scene) {
+	if scene.bindingsPath == "" {
+		return
+	}
+	if err := scene.bindings.Save(scene.bindingsPath); err != nil {
+		log.Print(err)
+	}
+}
+
+// actionPressed reports whether a key that does an action is held down.
+func actionPressed(scene *Scene, action input.Action) bool {
+	for _, key := range scene.bindings[action] {
+		if b, ok := keyButtons[key]; ok && scene.Window.Pressed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// actionJustPressed reports whether a key that does an action was pressed
+// this frame.
+func actionJustPressed(scene *Scene, action input.Action) bool {
+	for _, key := range scene.bindings[action] {
+		if b, ok := keyButtons[key]; ok && scene.Window.JustPressed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// justPressedKey returns a key that was pressed this frame.
+func justPressedKey(win *pixelgl.Window) (input.Key, bool) {
+	for key, b := range keyButtons {
+		if win.JustPressed(b) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// controlsState lets the player choose the keys for each action. Return
+// adds a key to the selected action, and backspace takes its last one away.
+// Changes are saved straight away.
+type controlsState struct {
+	back State
+	menu menu
+	// waiting is set while waiting for the key to add to the selected
+	// action.
+	waiting bool
+	// refused is why the last key chosen couldn't be added, until the next
+	// one is.
+	refused error
+}
+
+func (s *controlsState) Enter(scene *Scene) {
+	s.refresh(scene)
+}
+
+// refresh updates the menu to show the keys for every action.
+func (s *controlsState) refresh(scene *Scene) {
+	s.menu.items = s.menu.items[:0]
+	for _, action := range input.Actions {
+		keys := make([]string, len(scene.bindings[action]))
+		for i, key := range scene.bindings[action] {
+			keys[i] = string(key)
+		}
+		s.menu.items = append(s.menu.items, fmt.Sprintf("%s: %s", action, strings.Join(keys, ", ")))
+	}
+	s.menu.items = append(s.menu.items, "Reset to Defaults", "Back")
+}
+
+func (s *controlsState) Update(scene *Scene, dt float64) State {
+	win := scene.Window
+	defer s.refresh(scene)
+
+	if s.waiting {
+		if win.JustPressed(pixelgl.KeyEscape) {
+			s.waiting = false
+		} else if key, ok := justPressedKey(win); ok {
+			s.refused = scene.bindings.Bind(input.Actions[s.menu.selected], key)
+			if s.refused == nil {
+				saveBindings(scene)
+			}
+			s.waiting = false
+		}
+		return s
+	}
+
+	if win.JustPressed(pixelgl.KeyEscape) {
+		return s.back
+	}
+	if s.menu.selected < len(input.Actions) && win.JustPressed(pixelgl.KeyBackspace) {
+		// Every action keeps at least one key, so the game stays playable.
+		action := input.Actions[s.menu.selected]
+		if keys := scene.bindings[action]; len(keys) > 1 {
+			scene.bindings.Unbind(keys[len(keys)-1])
+			saveBindings(scene)
+		}
+	}
+
+	switch choice := s.menu.update(win); {
+	case choice < 0:
+	case choice < len(input.Actions):
+		s.waiting = true
+	case choice == len(input.Actions):
+		scene.bindings = input.Default()
+		saveBindings(scene)
+	default:
+		return s.back
+	}
+	return s
+}
+
+func (s *controlsState) Render(scene *Scene) {
+	renderWorld(scene, 1)
+	txt := screenText(scene, pixel.V(300, 550))
+	fmt.Fprintln(txt, "CONTROLS")
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 6))
+	s.menu.render(scene, pixel.V(200, 400))
+
+	hint := screenText(scene, pixel.V(200, 150))
+	switch {
+	case s.waiting:
+		fmt.Fprintf(hint, "Press a key for %s, or Escape to cancel", input.Actions[s.menu.selected])
+	case s.refused != nil:
+		fmt.Fprintf(hint, "Can't use that key: %v", s.refused)
+	default:
+		fmt.Fprint(hint, "Return adds a key, Backspace removes one")
+	}
+	hint.Draw(scene.Window, pixel.IM.Scaled(hint.Orig, 2))
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Hit describes where a moving box first touches another box.
+type Hit struct {
+	// Time is the fraction of the movement, from 0 to 1, at which the boxes
+	// first touch.
+	Time float64
+	// Normal is the side of the other box that was hit, pointing out of it.
+	// It is zero when the boxes already overlapped before moving.
+	Normal pixel.Vec
+}
+
+// Sweep moves box by delta and reports whether and when it touches other
+// along the way. Unlike testing for overlap after moving, it cannot miss a
+// box the movement passes straight through, however fast the movement is.
+func Sweep(box pixel.Rect, delta pixel.Vec, other pixel.Rect) (Hit, bool) {
+	// Grow the other box by the moving box's half size, so the problem becomes
+	// a ray from the moving box's center against the grown box.
+	half := box.Size().Scaled(0.5)
+	target := pixel.R(other.Min.X-half.X, other.Min.Y-half.Y, other.Max.X+half.X, other.Max.Y+half.Y)
+	origin := box.Center()
+
+	if target.Contains(origin) {
+		return Hit{}, true
+	}
+
+	entryX, exitX, ok := slab(origin.X, delta.X, target.Min.X, target.Max.X)
+	if !ok {
+		return Hit{}, false
+	}
+	entryY, exitY, ok := slab(origin.Y, delta.Y, target.Min.Y, target.Max.Y)
+	if !ok {
+		return Hit{}, false
+	}
+
+	entry := math.Max(entryX, entryY)
+	exit := math.Min(exitX, exitY)
+	if entry > exit || entry > 1 || entry < 0 {
+		return Hit{}, false
+	}
+
+	hit := Hit{Time: entry}
+	if entryX > entryY {
+		hit.Normal = pixel.V(-math.Copysign(1, delta.X), 0)
+	} else {
+		hit.Normal = pixel.V(0, -math.Copysign(1, delta.Y))
+	}
+	return hit, true
+}
+
+// slab returns the fractions of delta at which a point moving from origin
+// enters and leaves the range [min, max] along a single axis.
+func slab(origin, delta, min, max float64) (entry, exit float64, ok bool) {
+	if delta == 0 {
+		if origin < min || origin > max {
+			return 0, 0, false
+		}
+		return math.Inf(-1), math.Inf(1), true
+	}
+	entry = (min - origin) / delta
+	exit = (max - origin) / delta
+	if entry > exit {
+		entry, exit = exit, entry
+	}
+	return entry, exit, true
+}
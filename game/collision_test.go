@@ -0,0 +1,66 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestSweep(t *testing.T) {
+	box := pixel.R(-5, -5, 5, 5)
+	wall := pixel.R(100, -5, 110, 5)
+
+	tests := []struct {
+		name   string
+		box    pixel.Rect
+		delta  pixel.Vec
+		other  pixel.Rect
+		hit    bool
+		time   float64
+		normal pixel.Vec
+	}{
+		{"stops short", box, pixel.V(50, 0), wall, false, 0, pixel.ZV},
+		{"touches", box, pixel.V(95, 0), wall, true, 1, pixel.V(-1, 0)},
+		{"halfway", box, pixel.V(190, 0), wall, true, 0.5, pixel.V(-1, 0)},
+		{"tunnels at high speed", box, pixel.V(1e6, 0), wall, true, 95 / 1e6, pixel.V(-1, 0)},
+		{"passes above", pixel.R(-5, 15, 5, 25), pixel.V(1e6, 0), wall, false, 0, pixel.ZV},
+		{"moving away", box, pixel.V(-1e6, 0), wall, false, 0, pixel.ZV},
+		{"from above", pixel.R(100, 100, 110, 110), pixel.V(0, -1000), wall, true, 0.095, pixel.V(0, 1)},
+		{"already overlapping", pixel.R(98, -2, 102, 2), pixel.V(1e6, 1e6), wall, true, 0, pixel.ZV},
+		{"diagonal", box, pixel.V(200, 200), pixel.R(95, 85, 105, 95), true, 0.45, pixel.V(-1, 0)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hit, ok := Sweep(test.box, test.delta, test.other)
+			if ok != test.hit {
+				t.Fatalf("hit = %v, want %v", ok, test.hit)
+			}
+			if !ok {
+				return
+			}
+			if diff := hit.Time - test.time; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("time = %v, want %v", hit.Time, test.time)
+			}
+			if hit.Normal != test.normal {
+				t.Errorf("normal = %v, want %v", hit.Normal, test.normal)
+			}
+		})
+	}
+}
+
+func TestWorldCollidesAtHighSpeed(t *testing.T) {
+	w := NewWorld(DefaultConfig(), pixel.ZV, DefaultHitboxes, 1)
+	w.GroundSpeed = 1e6
+	w.Player.Velocity = pixel.V(0, -w.GroundSpeed)
+	w.AddObstacle(&Object{Kind: Server, Position: pixel.V(0, -1000)})
+
+	events := w.Step(Input{}, 1.0/120)
+	if !events.Has(Died) {
+		t.Fatalf("player passed through an obstacle at speed %v", w.GroundSpeed)
+	}
+
+	top := -1000 + DefaultHitboxes[Server].Bounds().H()/2 + DefaultHitboxes[Player].Bounds().H()/2
+	if w.Player.Position.Y != top {
+		t.Errorf("player stopped at y = %v, want %v", w.Player.Position.Y, top)
+	}
+}
@@ -0,0 +1,67 @@
+package game
+
+import "github.com/pkg/errors"
+
+// Config holds the numbers that tune how the game plays.
+type Config struct {
+	// Speed is the sideways speed of the player and the initial ground speed.
+	Speed float64 `json:"speed"`
+	// JumpSpeed is the initial downhill speed while jumping.
+	JumpSpeed float64 `json:"jump_speed"`
+	// JumpTime is how long a jump lasts, in seconds.
+	JumpTime float64 `json:"jump_time"`
+	// LevelMultiplier scales the ground and jump speeds on every level up.
+	LevelMultiplier float64 `json:"level_multiplier"`
+	// DifficultyDecay is how much the time between obstacles shrinks per
+	// second. It starts at one second.
+	DifficultyDecay float64 `json:"difficulty_decay"`
+	// LevelUpDifficulty is the time between obstacles at which the player
+	// levels up and it resets to one second.
+	LevelUpDifficulty float64 `json:"level_up_difficulty"`
+	// SpawnWidth is the width of the strip obstacles are spawned across.
+	SpawnWidth int `json:"spawn_width"`
+	// SpawnDistance is how far ahead of the player obstacles are spawned.
+	SpawnDistance float64 `json:"spawn_distance"`
+	// TrimDistance is how far behind the player obstacles are dropped.
+	TrimDistance float64 `json:"trim_distance"`
+}
+
+// DefaultConfig returns the tuning the game ships with.
+func DefaultConfig() Config {
+	return Config{
+		Speed:             300,
+		JumpSpeed:         500,
+		JumpTime:          0.9,
+		LevelMultiplier:   1.5,
+		DifficultyDecay:   .05,
+		LevelUpDifficulty: 0.5,
+		SpawnWidth:        1024,
+		SpawnDistance:     700,
+		TrimDistance:      400,
+	}
+}
+
+// Validate checks that the config describes a playable game.
+func (c Config) Validate() error {
+	switch {
+	case c.Speed <= 0:
+		return errors.New("speed must be positive")
+	case c.JumpSpeed <= 0:
+		return errors.New("jump_speed must be positive")
+	case c.JumpTime <= 0:
+		return errors.New("jump_time must be positive")
+	case c.LevelMultiplier < 1:
+		return errors.New("level_multiplier must be at least 1")
+	case c.DifficultyDecay <= 0:
+		return errors.New("difficulty_decay must be positive")
+	case c.LevelUpDifficulty <= 0 || c.LevelUpDifficulty >= 1:
+		return errors.New("level_up_difficulty must be between 0 and 1")
+	case c.SpawnWidth <= 0:
+		return errors.New("spawn_width must be positive")
+	case c.SpawnDistance <= 0:
+		return errors.New("spawn_distance must be positive")
+	case c.TrimDistance <= 0:
+		return errors.New("trim_distance must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package game
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Grid is a spatial index that buckets objects into square cells by their
+// position, so looking up the objects near a point doesn't depend on how many
+// objects there are in total.
+type Grid struct {
+	cellSize float64
+	cells    map[cell][]*Object
+	count    int
+}
+
+type cell struct{ x, y int }
+
+// NewGrid creates an empty grid with cells of the given size.
+func NewGrid(cellSize float64) *Grid {
+	return &Grid{
+		cellSize: cellSize,
+		cells:    make(map[cell][]*Object),
+	}
+}
+
+func (g *Grid) cellAt(pos pixel.Vec) cell {
+	return cell{
+		x: int(math.Floor(pos.X / g.cellSize)),
+		y: int(math.Floor(pos.Y / g.cellSize)),
+	}
+}
+
+// Len returns the number of objects in the grid.
+func (g *Grid) Len() int { return g.count }
+
+// Insert adds an object at its current position. Objects must be removed
+// before they are moved.
+func (g *Grid) Insert(o *Object) {
+	c := g.cellAt(o.Position)
+	g.cells[c] = append(g.cells[c], o)
+	g.count++
+}
+
+// Remove takes an object out of the grid, reporting whether it was there.
+func (g *Grid) Remove(o *Object) bool {
+	c := g.cellAt(o.Position)
+	objects := g.cells[c]
+	for i, other := range objects {
+		if other != o {
+			continue
+		}
+		objects[i] = objects[len(objects)-1]
+		objects[len(objects)-1] = nil
+		if len(objects) == 1 {
+			delete(g.cells, c)
+		} else {
+			g.cells[c] = objects[:len(objects)-1]
+		}
+		g.count--
+		return true
+	}
+	return false
+}
+
+// Query calls fn for every object whose position lies within r.
+func (g *Grid) Query(r pixel.Rect, fn func(o *Object)) {
+	min, max := g.cellAt(r.Min), g.cellAt(r.Max)
+	for y := min.y; y <= max.y; y++ {
+		for x := min.x; x <= max.x; x++ {
+			for _, o := range g.cells[cell{x, y}] {
+				if r.Contains(o.Position) {
+					fn(o)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"storj.io/snoboard/graphics"
+)
+
+func TestGrid(t *testing.T) {
+	g := NewGrid(100)
+	near := &Object{Position: pixel.V(10, 10)}
+	edge := &Object{Position: pixel.V(-50, 150)}
+	far := &Object{Position: pixel.V(5000, -5000)}
+	for _, o := range []*Object{near, edge, far} {
+		g.Insert(o)
+	}
+
+	query := func() map[*Object]bool {
+		found := map[*Object]bool{}
+		g.Query(pixel.R(-50, -50, 200, 200), func(o *Object) { found[o] = true })
+		return found
+	}
+
+	found := query()
+	if len(found) != 2 || !found[near] || !found[edge] {
+		t.Fatalf("query found %v, want near and edge", found)
+	}
+
+	if !g.Remove(edge) {
+		t.Fatal("edge was not removed")
+	}
+	if g.Remove(edge) {
+		t.Fatal("edge was removed twice")
+	}
+	if found := query(); len(found) != 1 || !found[near] {
+		t.Fatalf("query found %v, want near", found)
+	}
+	if g.Len() != 2 {
+		t.Fatalf("len = %d, want 2", g.Len())
+	}
+}
+
+func TestWorldTrimsObstaclesBehind(t *testing.T) {
+	w := NewWorld(DefaultConfig(), pixel.ZV, DefaultHitboxes, 1)
+	for i := 0; i < 10; i++ {
+		w.AddObstacle(&Object{Kind: Server, Position: pixel.V(5000, float64(1000-i*100))})
+	}
+	w.trimObstacles()
+	if len(w.Obstacles) != 4 || w.grid.Len() != 4 {
+		t.Fatalf("kept %d obstacles and %d indexed, want 4", len(w.Obstacles), w.grid.Len())
+	}
+}
+
+func BenchmarkDetectCollisions(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 10000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			w := NewWorld(DefaultConfig(), pixel.ZV, DefaultHitboxes, 1)
+			// Spread the obstacles down the slope the way they spawn, so
+			// adding more makes the slope longer rather than more crowded.
+			for i := 0; i < n; i++ {
+				x := float64(rng.Intn(2*w.Config.SpawnWidth) - w.Config.SpawnWidth)
+				w.AddObstacle(&Object{Kind: Server, Position: pixel.V(x, float64(-i*300))})
+			}
+			w.Player.Position = pixel.V(0, float64(-n*150))
+			w.Player.PrevPosition = w.Player.Position.Add(pixel.V(0, 5))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.detectCollisions()
+				w.Dead = false
+			}
+		})
+	}
+}
+
+// ellipseMask returns a mask of an ellipse filling a w by h sprite, the way
+// artwork leaves its corners transparent.
+func ellipseMask(w, h int) *graphics.Mask {
+	pic := pixel.MakePictureData(pixel.R(0, 0, float64(w), float64(h)))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx := (float64(x)+0.5)/float64(w)*2 - 1
+			dy := (float64(y)+0.5)/float64(h)*2 - 1
+			if dx*dx+dy*dy <= 1 {
+				pic.Pix[y*pic.Stride+x] = color.RGBA{A: 255}
+			}
+		}
+	}
+	return graphics.NewMask(pic, pic.Bounds(), 0)
+}
+
+func BenchmarkDetectCollisionsMasks(b *testing.B) {
+	hitboxes := Hitboxes{
+		Player: ellipseMask(119, 200),
+		Server: ellipseMask(135, 270),
+	}
+	w := NewWorld(DefaultConfig(), pixel.ZV, hitboxes, 1)
+	// Place the player in the corner of the server's bounds, where only
+	// transparent pixels meet, so every substep scans without a hit.
+	w.AddObstacle(&Object{Kind: Server, Position: pixel.V(110, -215)})
+	w.Player.Position = pixel.V(0, -10)
+	w.Player.PrevPosition = pixel.ZV
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.detectCollisions()
+		w.Dead = false
+	}
+}
@@ -0,0 +1,274 @@
+package game
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+)
+
+// replayMagic starts every replay file, followed by the format version.
+const (
+	replayMagic   = "SNOR"
+	replayVersion = 2
+)
+
+// maxReplayConfig is the longest config a replay header may hold, so a
+// corrupt length can't make ReadReplay allocate without bound.
+const maxReplayConfig = 1 << 16
+
+// maxReplayDT and maxReplayFrames bound a replay's time steps and how many
+// of them it holds, a day's worth at a thousand steps a second, so a corrupt
+// file can't feed the world nonsense or play back forever.
+const (
+	maxReplayDT     = 1.0
+	maxReplayFrames = 24 * 60 * 60 * 1000
+)
+
+// Frame is the input and time step fed to a single World.Step call.
+type Frame struct {
+	Input Input
+	DT    float64
+}
+
+// run is a frame repeated count times in a row. Replays are stored as runs
+// since the input rarely changes between frames.
+type run struct {
+	Frame
+	count uint64
+}
+
+func (in Input) bits() byte {
+	var b byte
+	for i, pressed := range []bool{in.Left, in.Right, in.Jump, in.Restart} {
+		if pressed {
+			b |= 1 << uint(i)
+		}
+	}
+	return b
+}
+
+func inputFromBits(b byte) Input {
+	return Input{
+		Left:    b&1 != 0,
+		Right:   b&2 != 0,
+		Jump:    b&4 != 0,
+		Restart: b&8 != 0,
+	}
+}
+
+// Recorder writes every frame of a run to a replay file. Write errors are
+// held by the underlying buffer and reported by Close.
+type Recorder struct {
+	w    *bufio.Writer
+	last run
+}
+
+// NewRecorder starts a replay of a run of world. A run depends on the
+// world's seed, config and hitboxes as much as on the input, so they are
+// written first.
+func NewRecorder(w io.Writer, world *World) *Recorder {
+	r := &Recorder{w: bufio.NewWriter(w)}
+	r.w.WriteString(replayMagic)
+	r.w.WriteByte(replayVersion)
+	var buf [binary.MaxVarintLen64]byte
+	r.w.Write(buf[:binary.PutVarint(buf[:], world.Seed)])
+	// A Config only holds numbers, so it always marshals.
+	cfg, _ := json.Marshal(world.Config)
+	r.w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(cfg)))])
+	r.w.Write(cfg)
+	binary.LittleEndian.PutUint64(buf[:], fingerprint(world.Hitboxes))
+	r.w.Write(buf[:8])
+	return r
+}
+
+// fingerprint hashes the shape of every hitbox, sampled at the center of
+// each pixel of its bounds, so replays can tell whether they are played
+// back with the hitboxes they were recorded with.
+func fingerprint(hitboxes Hitboxes) uint64 {
+	kinds := make([]int, 0, len(hitboxes))
+	for kind := range hitboxes {
+		kinds = append(kinds, int(kind))
+	}
+	sort.Ints(kinds)
+
+	h := fnv.New64a()
+	var buf [8]byte
+	write := func(v float64) {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	for _, kind := range kinds {
+		hitbox := hitboxes[Kind(kind)]
+		b := hitbox.Bounds()
+		write(float64(kind))
+		for _, v := range []float64{b.Min.X, b.Min.Y, b.Max.X, b.Max.Y} {
+			write(v)
+		}
+		var cells []byte
+		for y := math.Floor(b.Min.Y); y < b.Max.Y; y++ {
+			for x := math.Floor(b.Min.X); x < b.Max.X; x++ {
+				cell := byte(0)
+				if hitbox.Contains(pixel.V(x+0.5, y+0.5)) {
+					cell = 1
+				}
+				cells = append(cells, cell)
+			}
+		}
+		h.Write(cells)
+	}
+	return h.Sum64()
+}
+
+// Record appends a frame to the replay.
+func (r *Recorder) Record(in Input, dt float64) {
+	frame := Frame{Input: in, DT: dt}
+	if r.last.count > 0 && r.last.Frame == frame {
+		r.last.count++
+		return
+	}
+	r.flushRun()
+	r.last = run{Frame: frame, count: 1}
+}
+
+// Close writes any buffered frames. It does not close the underlying writer.
+func (r *Recorder) Close() error {
+	r.flushRun()
+	return r.w.Flush()
+}
+
+func (r *Recorder) flushRun() {
+	if r.last.count == 0 {
+		return
+	}
+	var buf [binary.MaxVarintLen64]byte
+	r.w.WriteByte(r.last.Input.bits())
+	r.w.Write(buf[:binary.PutUvarint(buf[:], r.last.count)])
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(r.last.DT))
+	r.w.Write(buf[:8])
+	r.last.count = 0
+}
+
+// Replay is a recorded run that can be played back frame by frame. It only
+// plays back the same in a world created with its seed, config and
+// hitboxes.
+type Replay struct {
+	Seed   int64
+	Config Config
+	// hitboxes is the fingerprint of the hitboxes the run was played with.
+	hitboxes uint64
+	runs     []run
+	pos      int
+	used     uint64
+}
+
+// ReadReplay reads a replay written by a Recorder.
+func ReadReplay(r io.Reader) (replay *Replay, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error reading replay")
+		}
+	}()
+
+	br := bufio.NewReader(r)
+	header := make([]byte, len(replayMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(replayMagic)]) != replayMagic {
+		return nil, errors.New("not a replay file")
+	}
+	if header[len(replayMagic)] != replayVersion {
+		return nil, errors.Errorf("unsupported replay version %d", header[len(replayMagic)])
+	}
+
+	// Like a config file, settings missing from the header keep their
+	// default values.
+	replay = &Replay{Config: DefaultConfig()}
+	if replay.Seed, err = binary.ReadVarint(br); err != nil {
+		return nil, err
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxReplayConfig {
+		return nil, errors.Errorf("config of %d bytes is too long", size)
+	}
+	cfg := make([]byte, size)
+	if _, err := io.ReadFull(br, cfg); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(cfg, &replay.Config); err != nil {
+		return nil, errors.Wrap(err, "bad config")
+	}
+	if err := replay.Config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "bad config")
+	}
+	var fp [8]byte
+	if _, err := io.ReadFull(br, fp[:]); err != nil {
+		return nil, err
+	}
+	replay.hitboxes = binary.LittleEndian.Uint64(fp[:])
+
+	var frames uint64
+	for {
+		bits, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		count, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if count > maxReplayFrames-frames {
+			return nil, errors.Errorf("more than %d frames", maxReplayFrames)
+		}
+		frames += count
+		var buf [8]byte
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, err
+		}
+		dt := math.Float64frombits(binary.LittleEndian.Uint64(buf[:]))
+		// NaN fails every comparison, so check for the good range.
+		if !(dt > 0 && dt <= maxReplayDT) {
+			return nil, errors.Errorf("bad time step %v", dt)
+		}
+		replay.runs = append(replay.runs, run{
+			Frame: Frame{Input: inputFromBits(bits), DT: dt},
+			count: count,
+		})
+	}
+	return replay, nil
+}
+
+// CheckHitboxes reports an error unless hitboxes are the ones the replay was
+// recorded with. Collisions with any others would play out differently.
+func (r *Replay) CheckHitboxes(hitboxes Hitboxes) error {
+	if fingerprint(hitboxes) != r.hitboxes {
+		return errors.New("replay was recorded with different hitboxes, so it can't be played back")
+	}
+	return nil
+}
+
+// Next returns the next frame of the replay, or false once it has ended.
+func (r *Replay) Next() (Frame, bool) {
+	for r.pos < len(r.runs) && r.used >= r.runs[r.pos].count {
+		r.pos++
+		r.used = 0
+	}
+	if r.pos >= len(r.runs) {
+		return Frame{}, false
+	}
+	r.used++
+	return r.runs[r.pos].Frame, true
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"storj.io/snoboard/graphics"
+)
+
+func TestReplayReproducesRun(t *testing.T) {
+	const seed = 42
+	cfg := DefaultConfig()
+	cfg.Speed = 350
+	var buf bytes.Buffer
+	w := NewWorld(cfg, pixel.ZV, DefaultHitboxes, seed)
+	rec := NewRecorder(&buf, w)
+
+	// Weave about, jumping now and then, until well after a crash.
+	for i := 0; i < 120*60; i++ {
+		in := Input{
+			Left:    i/90%3 == 0,
+			Right:   i/90%3 == 2,
+			Jump:    i%200 < 5,
+			Restart: i == 0,
+		}
+		dt := testStep
+		if i%500 == 0 {
+			dt = 0.1 // A slow frame.
+		}
+		rec.Record(in, dt)
+		w.Step(in, dt)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Seed != seed {
+		t.Fatalf("seed is %d, want %d", replay.Seed, seed)
+	}
+	if replay.Config != cfg {
+		t.Fatalf("config is %+v, want %+v", replay.Config, cfg)
+	}
+	if err := replay.CheckHitboxes(DefaultHitboxes); err != nil {
+		t.Fatal(err)
+	}
+	played := NewWorld(replay.Config, pixel.ZV, DefaultHitboxes, replay.Seed)
+	frames := 0
+	for frame, ok := replay.Next(); ok; frame, ok = replay.Next() {
+		played.Step(frame.Input, frame.DT)
+		frames++
+	}
+
+	if frames != 120*60 {
+		t.Errorf("replayed %d frames, want %d", frames, 120*60)
+	}
+	if played.Score() != w.Score() || played.Level != w.Level || played.Dead != w.Dead {
+		t.Errorf("replay ended with score %v, level %v, dead %v, want %v, %v, %v",
+			played.Score(), played.Level, played.Dead, w.Score(), w.Level, w.Dead)
+	}
+	if w.Score() == 0 {
+		t.Error("the run went nowhere, so the test proves nothing")
+	}
+}
+
+func TestReplayCheckHitboxes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewRecorder(&buf, NewWorld(DefaultConfig(), pixel.ZV, DefaultHitboxes, 1)).Close(); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moved := Hitboxes{}
+	for kind, hitbox := range DefaultHitboxes {
+		moved[kind] = hitbox
+	}
+	moved[Player] = graphics.Box(DefaultHitboxes[Player].Bounds().Moved(pixel.V(1, 0)))
+	if err := replay.CheckHitboxes(moved); err == nil {
+		t.Error("moved player hitbox was accepted")
+	}
+	if err := replay.CheckHitboxes(DefaultHitboxes); err != nil {
+		t.Errorf("recorded hitboxes were refused: %v", err)
+	}
+}
+
+// header returns a version 2 replay header for seed 42 with the given config
+// JSON and a hitbox fingerprint of zero.
+func header(cfg string) string {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(cfg)))
+	return "SNOR\x02\x54" + string(size[:n]) + cfg + "\x00\x00\x00\x00\x00\x00\x00\x00"
+}
+
+// runOf returns a run of count frames dt long, with no keys held.
+func runOf(count uint64, dt float64) string {
+	var buf [1 + binary.MaxVarintLen64 + 8]byte
+	n := 1 + binary.PutUvarint(buf[1:], count)
+	binary.LittleEndian.PutUint64(buf[n:], math.Float64bits(dt))
+	return string(buf[:n+8])
+}
+
+func TestReadReplayMalformed(t *testing.T) {
+	cfg, err := json.Marshal(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := header(string(cfg))
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", "", "EOF"},
+		{"bad magic", "SNOX" + valid[4:], "not a replay file"},
+		{"old version", "SNOR\x01\x54", "unsupported replay version 1"},
+		{"wrong version", "SNOR\x09\x54", "unsupported replay version 9"},
+		{"truncated seed", "SNOR\x02\x80", "EOF"},
+		{"truncated config size", "SNOR\x02\x54\x80", "EOF"},
+		{"truncated config", valid[:len(valid)-20], "EOF"},
+		{"config too long", "SNOR\x02\x54\xff\xff\xff\x7f", "too long"},
+		{"config not JSON", header("{"), "bad config"},
+		{"invalid config", header(`{"speed":-1}`), "bad config: speed must be positive"},
+		{"truncated hitboxes", valid[:len(valid)-3], "EOF"},
+		{"truncated count", valid + "\x00\x80", "EOF"},
+		{"truncated dt", valid + "\x00\x01\x00\x00", "EOF"},
+		{"NaN dt", valid + runOf(1, math.NaN()), "bad time step NaN"},
+		{"infinite dt", valid + runOf(1, math.Inf(1)), "bad time step +Inf"},
+		{"negative dt", valid + runOf(1, -testStep), "bad time step"},
+		{"zero dt", valid + runOf(1, 0), "bad time step 0"},
+		{"huge dt", valid + runOf(1, 60), "bad time step 60"},
+		{"huge count", valid + runOf(math.MaxUint64, testStep), "more than"},
+		{"too many frames in all", valid + runOf(maxReplayFrames, testStep) + runOf(1, testStep), "more than"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadReplay(strings.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package game
+
+// Timestep turns variable frame times into a whole number of fixed
+// simulation steps, so physics behaves the same on slow and fast machines.
+type Timestep struct {
+	// Step is the length of one simulation step, in seconds.
+	Step float64
+	// MaxSteps caps how many steps a single frame may catch up on. Time
+	// beyond that is dropped, so a long stall slows the game down instead of
+	// freezing it while it catches up.
+	MaxSteps int
+
+	accumulator float64
+}
+
+// NewTimestep creates a timestep running rate steps per second.
+func NewTimestep(rate float64, maxSteps int) *Timestep {
+	return &Timestep{Step: 1 / rate, MaxSteps: maxSteps}
+}
+
+// Advance adds the time a frame took and returns how many steps to run.
+func (t *Timestep) Advance(frame float64) int {
+	t.accumulator += frame
+	steps := int(t.accumulator / t.Step)
+	if steps > t.MaxSteps {
+		steps = t.MaxSteps
+		t.accumulator = float64(steps) * t.Step
+	}
+	t.accumulator -= float64(steps) * t.Step
+	return steps
+}
+
+// Alpha is how far the leftover time is towards the next step, from 0 to 1.
+// Renderers use it to interpolate between the last two states.
+func (t *Timestep) Alpha() float64 {
+	return t.accumulator / t.Step
+}
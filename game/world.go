@@ -0,0 +1,342 @@
+// Package game implements the SNOboard gameplay rules. It knows nothing about
+// windows or rendering, so a run can be stepped headlessly from plain input.
+package game
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+	"storj.io/snoboard/graphics"
+)
+
+const (
+	// collisionStep is the furthest the player moves between two checks of
+	// the exact collision shapes.
+	collisionStep = 2
+	// maxCollisionSteps caps the shape checks made for one obstacle per step.
+	maxCollisionSteps = 256
+	// gridCellSize is the size of the cells obstacles are indexed by.
+	gridCellSize = 512
+)
+
+// Kind identifies what an object in the world is.
+type Kind int
+
+const (
+	Player Kind = iota
+	Server
+	HardDrive
+)
+
+// Hitboxes holds the collision shape for each kind of object.
+type Hitboxes map[Kind]graphics.Hitbox
+
+// DefaultHitboxes are boxes the size of the sprites in graphics/dj.
+var DefaultHitboxes = Hitboxes{
+	Player:    graphics.CenteredBox(119, 200),
+	Server:    graphics.CenteredBox(135, 270),
+	HardDrive: graphics.CenteredBox(136, 160),
+}
+
+// Rand is the source of randomness used to place obstacles. It is reseeded
+// with the world's seed on every restart. *rand.Rand satisfies it.
+type Rand interface {
+	Intn(n int) int
+	Seed(seed int64)
+}
+
+// Input is the state of the controls for a single step.
+type Input struct {
+	Left  bool
+	Right bool
+	Jump  bool
+	// Restart starts a new run, whether or not the player has crashed.
+	Restart bool
+}
+
+// Events reports what happened during a step.
+type Events uint8
+
+const (
+	Died Events = 1 << iota
+	Jumped
+	LeveledUp
+	Restarted
+)
+
+// Has reports whether all of the events in e2 are set in e.
+func (e Events) Has(e2 Events) bool { return e&e2 == e2 }
+
+// Object represents an item in the game (player, obstacle, etc...)
+type Object struct {
+	Kind         Kind
+	Position     pixel.Vec
+	PrevPosition pixel.Vec
+	Velocity     pixel.Vec
+}
+
+// Interpolated returns the position of the object alpha of the way from its
+// position before the last step to its current one.
+func (o *Object) Interpolated(alpha float64) pixel.Vec {
+	return pixel.Lerp(o.PrevPosition, o.Position, alpha)
+}
+
+// World holds the game state and steps it according to the game rules.
+type World struct {
+	Config                Config
+	Rand                  Rand
+	Seed                  int64
+	Start                 pixel.Vec
+	Hitboxes              Hitboxes
+	Player                *Object
+	Obstacles             []*Object
+	grid                  *Grid
+	Steering              float64
+	TimeSinceLastObstacle float64
+	Difficulty            float64
+	Level                 float64
+	Dead                  bool
+	Jumping               bool
+	JumpSpeed             float64
+	GroundSpeed           float64
+	TimeSinceJump         float64
+}
+
+// NewWorld creates a world with the player standing at start. The seed
+// decides where obstacles are placed, so two worlds with the same config,
+// seed and input play out identically.
+func NewWorld(cfg Config, start pixel.Vec, hitboxes Hitboxes, seed int64) *World {
+	w := &World{
+		Config:   cfg,
+		Rand:     rand.New(rand.NewSource(seed)),
+		Seed:     seed,
+		Start:    start,
+		Hitboxes: hitboxes,
+		Player: &Object{
+			Kind:         Player,
+			Position:     start,
+			PrevPosition: start,
+		},
+		grid: NewGrid(gridCellSize),
+	}
+	w.resetDifficulty()
+	return w
+}
+
+// Score is the distance travelled downhill, scaled down.
+func (w *World) Score() float64 {
+	distance := w.Player.Position.Y
+	if distance > 0 {
+		return 0
+	}
+	return distance * -1 / 3
+}
+
+// Step advances the world by dt seconds using the given input.
+func (w *World) Step(in Input, dt float64) Events {
+	w.TimeSinceLastObstacle += dt
+	w.Player.PrevPosition = w.Player.Position
+
+	events := w.processInput(in, dt)
+	return events | w.updateState(dt)
+}
+
+func (w *World) resetDifficulty() {
+	w.Level = 0
+	w.Difficulty = 1
+	w.GroundSpeed = w.Config.Speed
+	w.JumpSpeed = w.Config.JumpSpeed
+	w.Player.Velocity = pixel.V(0, -w.GroundSpeed)
+}
+
+func (w *World) processInput(in Input, dt float64) Events {
+	var events Events
+
+	if in.Restart {
+		w.Dead = false
+		w.Player.Position = w.Start
+		w.Player.PrevPosition = w.Start
+		w.Jumping = false
+		w.TimeSinceLastObstacle = 0
+		w.resetDifficulty()
+		w.Obstacles = []*Object{}
+		w.grid = NewGrid(gridCellSize)
+		w.Rand.Seed(w.Seed)
+		events |= Restarted
+	}
+
+	// After a crash the controls do nothing until the next restart.
+	w.Steering = 0
+	if w.Dead {
+		return events
+	}
+	if in.Left {
+		w.Steering--
+	}
+	if in.Right {
+		w.Steering++
+	}
+	if in.Jump && !w.Jumping {
+		w.Jumping = true
+		w.TimeSinceJump = 0
+		w.Player.Velocity = pixel.V(0, -w.JumpSpeed)
+		events |= Jumped
+	}
+
+	player := w.Player
+	player.Velocity = pixel.V(w.Steering*w.Config.Speed, player.Velocity.Y)
+	player.Position = player.Position.Add(player.Velocity.Scaled(dt))
+	return events
+}
+
+func (w *World) updateState(dt float64) Events {
+	if w.Dead {
+		return 0
+	}
+	if w.Jumping {
+		w.TimeSinceJump += dt
+		if w.TimeSinceJump > w.Config.JumpTime {
+			w.Jumping = false
+			w.Player.Velocity = pixel.V(0, -w.GroundSpeed)
+		}
+	}
+	player := w.Player
+	events := w.detectCollisions()
+	w.trimObstacles()
+
+	// If it has been 1 second since last obstacle then create a new one
+	if w.TimeSinceLastObstacle > w.Difficulty {
+		spawnWidth := w.Config.SpawnWidth
+		randX := w.Rand.Intn(2*spawnWidth) - spawnWidth
+		kind := HardDrive
+		if w.Rand.Intn(2) == 0 {
+			kind = Server
+		}
+		w.AddObstacle(&Object{
+			Kind:     kind,
+			Position: player.Position.Add(pixel.V(float64(randX), -w.Config.SpawnDistance)),
+		})
+		w.TimeSinceLastObstacle = 0
+	}
+
+	return events | w.increaseDifficulty(dt)
+}
+
+// AddObstacle places an obstacle in the world.
+func (w *World) AddObstacle(o *Object) {
+	w.Obstacles = append(w.Obstacles, o)
+	w.grid.Insert(o)
+}
+
+// trimObstacles drops the obstacles the player has left behind. Obstacles are
+// spawned below a player who only ever moves downhill, so they are ordered by
+// height and the ones behind are always at the front.
+func (w *World) trimObstacles() {
+	var trimmed int
+	for _, o := range w.Obstacles {
+		if o.Position.Y <= w.Player.Position.Y+w.Config.TrimDistance {
+			break
+		}
+		w.grid.Remove(o)
+		trimmed++
+	}
+	for i := 0; i < trimmed; i++ {
+		w.Obstacles[i] = nil
+	}
+	w.Obstacles = w.Obstacles[trimmed:]
+}
+
+func (w *World) increaseDifficulty(dt float64) Events {
+	w.Difficulty -= dt * w.Config.DifficultyDecay
+
+	if w.Difficulty >= w.Config.LevelUpDifficulty {
+		return 0
+	}
+	w.Level++
+	w.Difficulty = 1
+	w.GroundSpeed = w.GroundSpeed * w.Config.LevelMultiplier
+	w.JumpSpeed = w.JumpSpeed * w.Config.LevelMultiplier
+	if w.Jumping {
+		w.Player.Velocity = pixel.V(w.Player.Velocity.X, -w.JumpSpeed)
+	} else {
+		w.Player.Velocity = pixel.V(w.Player.Velocity.X, -w.GroundSpeed)
+	}
+	return LeveledUp
+}
+
+func (w *World) detectCollisions() Events {
+	player := w.Player
+	delta := player.Position.Sub(player.PrevPosition)
+
+	// Only look at obstacles close enough to the player's path to touch it.
+	area := w.Hitboxes[Player].Bounds().Moved(player.PrevPosition)
+	area = area.Union(area.Moved(delta))
+	reach := w.obstacleReach()
+	area = pixel.R(area.Min.X-reach, area.Min.Y-reach, area.Max.X+reach, area.Max.Y+reach)
+
+	first, hit := 0.0, false
+	w.grid.Query(area, func(obstacle *Object) {
+		if obstacle.Kind == HardDrive && w.Jumping {
+			return
+		}
+		t, ok := w.collides(obstacle, player.PrevPosition, delta)
+		if ok && (!hit || t < first) {
+			first, hit = t, true
+		}
+	})
+	if !hit {
+		return 0
+	}
+
+	// Stop the player where they hit the obstacle rather than past it.
+	player.Position = player.PrevPosition.Add(delta.Scaled(first))
+	w.Dead = true
+	return Died
+}
+
+// obstacleReach is the furthest any obstacle's hitbox reaches from its position.
+func (w *World) obstacleReach() float64 {
+	var reach float64
+	for kind, hitbox := range w.Hitboxes {
+		if kind == Player {
+			continue
+		}
+		b := hitbox.Bounds()
+		reach = math.Max(reach, math.Max(math.Max(-b.Min.X, b.Max.X), math.Max(-b.Min.Y, b.Max.Y)))
+	}
+	return reach
+}
+
+// collides reports whether the player moving by delta from pos touches the
+// obstacle, and at what fraction of the movement.
+func (w *World) collides(obstacle *Object, pos, delta pixel.Vec) (float64, bool) {
+	playerBox, obstacleBox := w.Hitboxes[Player], w.Hitboxes[obstacle.Kind]
+	hit, ok := Sweep(playerBox.Bounds().Moved(pos), delta, obstacleBox.Bounds().Moved(obstacle.Position))
+	if !ok {
+		return 0, false
+	}
+	_, playerIsBox := playerBox.(graphics.Box)
+	_, obstacleIsBox := obstacleBox.(graphics.Box)
+	if playerIsBox && obstacleIsBox {
+		return hit.Time, true
+	}
+
+	// The bounds touch somewhere along the movement, so walk the rest of it in
+	// small steps to find where the shapes themselves first touch.
+	remaining := 1 - hit.Time
+	steps := int(math.Ceil(delta.Len() * remaining / collisionStep))
+	if steps > maxCollisionSteps {
+		steps = maxCollisionSteps
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		t := hit.Time + remaining*float64(i)/float64(steps)
+		if graphics.Overlaps(playerBox, pos.Add(delta.Scaled(t)), obstacleBox, obstacle.Position) {
+			return t, true
+		}
+	}
+	return 0, false
+}
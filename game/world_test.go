@@ -0,0 +1,174 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+const testStep = 1.0 / 120
+
+// steps advances the world by n steps of the same input and returns every
+// event that happened.
+func steps(w *World, in Input, n int) Events {
+	var events Events
+	for i := 0; i < n; i++ {
+		events |= w.Step(in, testStep)
+	}
+	return events
+}
+
+// emptyWorld returns a world whose obstacles spawn far off to the side, so
+// the player never hits one.
+func emptyWorld() *World {
+	cfg := DefaultConfig()
+	w := NewWorld(cfg, pixel.ZV, DefaultHitboxes, 1)
+	w.Rand = sideRand{}
+	return w
+}
+
+// sideRand always picks the rightmost spawn position.
+type sideRand struct{}
+
+func (sideRand) Intn(n int) int  { return n - 1 }
+func (sideRand) Seed(seed int64) {}
+
+func TestWorldJump(t *testing.T) {
+	w := emptyWorld()
+	if events := w.Step(Input{Jump: true}, testStep); !events.Has(Jumped) {
+		t.Fatal("jumping didn't report Jumped")
+	}
+	if !w.Jumping || w.Player.Velocity.Y != -w.Config.JumpSpeed {
+		t.Fatalf("jumping = %v at %v, want jumping at jump speed", w.Jumping, w.Player.Velocity)
+	}
+	// Holding jump doesn't start another one.
+	if events := w.Step(Input{Jump: true}, testStep); events.Has(Jumped) {
+		t.Fatal("holding jump jumped again")
+	}
+
+	// The jump lands once JumpTime has passed, and not before.
+	air := int(w.Config.JumpTime / testStep)
+	steps(w, Input{}, air-2)
+	if !w.Jumping {
+		t.Fatalf("landed %v early", w.Config.JumpTime-w.TimeSinceJump)
+	}
+	steps(w, Input{}, 2)
+	if w.Jumping || w.Player.Velocity.Y != -w.GroundSpeed {
+		t.Fatalf("jumping = %v at %v after %v, want landed at ground speed",
+			w.Jumping, w.Player.Velocity, w.TimeSinceJump)
+	}
+}
+
+func TestWorldLevelUp(t *testing.T) {
+	w := emptyWorld()
+	cfg := w.Config
+	// Difficulty decays from 1 to LevelUpDifficulty before the first level.
+	seconds := (1 - cfg.LevelUpDifficulty) / cfg.DifficultyDecay
+	n := int(seconds / testStep)
+
+	if events := steps(w, Input{}, n-1); events.Has(LeveledUp) || w.Level != 0 {
+		t.Fatalf("leveled up to %v before %v seconds", w.Level, seconds)
+	}
+	if events := steps(w, Input{}, 2); !events.Has(LeveledUp) || w.Level != 1 {
+		t.Fatalf("level is %v after %v seconds, want 1", w.Level, seconds)
+	}
+	if want := cfg.Speed * cfg.LevelMultiplier; w.GroundSpeed != want || w.Player.Velocity.Y != -want {
+		t.Errorf("ground speed is %v at %v, want %v", w.GroundSpeed, w.Player.Velocity, want)
+	}
+	if w.Difficulty != 1 {
+		t.Errorf("difficulty is %v after leveling up, want 1", w.Difficulty)
+	}
+}
+
+func TestWorldSpawnsAndTrimsObstacles(t *testing.T) {
+	w := emptyWorld()
+	cfg := w.Config
+
+	// The first obstacle comes SpawnDistance ahead once the time since the
+	// start passes the difficulty, which decays from one second meanwhile.
+	spawn := 1 / (1 + cfg.DifficultyDecay)
+	steps(w, Input{}, int(spawn/testStep)-1)
+	if len(w.Obstacles) != 0 {
+		t.Fatalf("spawned %d obstacles before %v seconds", len(w.Obstacles), spawn)
+	}
+	steps(w, Input{}, 2)
+	if len(w.Obstacles) != 1 {
+		t.Fatalf("spawned %d obstacles after %v seconds, want 1", len(w.Obstacles), spawn)
+	}
+	first := w.Obstacles[0]
+	want := pixel.V(float64(cfg.SpawnWidth-1), w.Player.Position.Y-cfg.SpawnDistance)
+	if first.Position.Sub(want).Len() > w.GroundSpeed*2*testStep {
+		t.Errorf("spawned at %v, want about %v", first.Position, want)
+	}
+
+	// Obstacles go once the player is TrimDistance past them.
+	for w.Player.Position.Y > first.Position.Y-cfg.TrimDistance-1 {
+		w.Step(Input{}, testStep)
+	}
+	for _, o := range w.Obstacles {
+		if o == first {
+			t.Fatalf("kept an obstacle %v behind the player", w.Player.Position.Y-o.Position.Y)
+		}
+	}
+}
+
+func TestWorldDeathAndRestart(t *testing.T) {
+	w := emptyWorld()
+	w.AddObstacle(&Object{Kind: Server, Position: pixel.V(0, -100)})
+	if events := steps(w, Input{}, 60); !events.Has(Died) || !w.Dead {
+		t.Fatal("rode through a server")
+	}
+
+	// Nothing moves, and the controls do nothing, after a crash.
+	crashed := w.Player.Position
+	events := steps(w, Input{Left: true, Jump: true}, 60)
+	if events != 0 || w.Jumping || w.Steering != 0 || w.Player.Position != crashed {
+		t.Errorf("after a crash got events %b, jumping %v, steering %v at %v, want nothing",
+			events, w.Jumping, w.Steering, w.Player.Position)
+	}
+
+	events = w.Step(Input{Restart: true}, testStep)
+	if !events.Has(Restarted) || w.Dead || len(w.Obstacles) != 0 || w.Level != 0 {
+		t.Fatalf("restart left dead %v with %d obstacles at level %v", w.Dead, len(w.Obstacles), w.Level)
+	}
+	if w.Player.Position.Y >= 0 || w.Player.Position.X != 0 {
+		t.Errorf("player is at %v after restarting, want just below the start", w.Player.Position)
+	}
+}
+
+func TestWorldRestartReseeds(t *testing.T) {
+	// placements returns where the first few obstacles of a run spawn.
+	placements := func(w *World) []float64 {
+		w.Step(Input{Restart: true}, testStep)
+		steps(w, Input{}, 120*5)
+		var xs []float64
+		for _, o := range w.Obstacles {
+			xs = append(xs, o.Position.X)
+		}
+		return xs
+	}
+	equal := func(a, b []float64) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	w := NewWorld(DefaultConfig(), pixel.ZV, DefaultHitboxes, 1)
+	first := placements(w)
+	if len(first) == 0 {
+		t.Fatal("no obstacles spawned, so the test proves nothing")
+	}
+	if again := placements(w); !equal(again, first) {
+		t.Errorf("restarting with the same seed placed obstacles at %v, want %v", again, first)
+	}
+	w.Seed = 2
+	if other := placements(w); equal(other, first) {
+		t.Errorf("restarting with a new seed placed obstacles at the same %v", other)
+	}
+}
@@ -1,5 +1,7 @@
 module storj.io/snoboard
 
+go 1.16
+
 require (
 	github.com/faiface/beep v1.0.1
 	github.com/faiface/glhf v0.0.0-20181018222622-82a6317ac380 // indirect
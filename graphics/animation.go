@@ -0,0 +1,159 @@
+package graphics
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/faiface/pixel"
+)
+
+// Frame is a single image of a clip.
+type Frame struct {
+	// Rect is where the frame is in the sheet.
+	Rect pixel.Rect
+	// Offset is how far the center of Rect is from the frame's pivot, the
+	// point that lands on the position the frame is drawn at. It is zero for
+	// frames pivoted on their center that weren't trimmed.
+	Offset pixel.Vec
+	// Duration is how long the frame is shown, in seconds. Zero means the
+	// clip's FPS decides.
+	Duration float64
+	// Density is how many pixels of the sheet make up one pixel of the frame
+	// as drawn, 2 for frames cut from an @2x sheet. Zero means 1.
+	Density float64
+}
+
+// scale returns how much the frame is scaled by when drawn.
+func (f Frame) scale() float64 {
+	if f.Density == 0 {
+		return 1
+	}
+	return 1 / f.Density
+}
+
+// Clip is an animation made of frames from a sprite sheet.
+type Clip struct {
+	Frames []Frame
+	// FPS is how many frames are shown per second, for frames without a
+	// duration of their own.
+	FPS float64
+	// Loop makes the clip start over after its last frame. Clips that don't
+	// loop stop on their last frame.
+	Loop bool
+}
+
+// frameDuration returns how long frame i is shown.
+func (c *Clip) frameDuration(i int) float64 {
+	if d := c.Frames[i].Duration; d > 0 {
+		return d
+	}
+	return 1 / c.FPS
+}
+
+// Length returns how long the clip takes to play once, in seconds.
+func (c *Clip) Length() float64 {
+	var length float64
+	for i := range c.Frames {
+		length += c.frameDuration(i)
+	}
+	return length
+}
+
+// frameAt returns the index of the frame shown t seconds into the clip.
+func (c *Clip) frameAt(t float64) int {
+	if c.Loop {
+		t = math.Mod(t, c.Length())
+	}
+	for i := range c.Frames {
+		t -= c.frameDuration(i)
+		if t < 0 {
+			return i
+		}
+	}
+	return len(c.Frames) - 1
+}
+
+// Animator plays clips from a sprite sheet.
+type Animator struct {
+	sheet  pixel.Picture
+	clips  map[string]*Clip
+	sprite *pixel.Sprite
+	name   string
+	time   float64
+	ended  bool
+}
+
+// NewAnimator creates an animator for clips in sheet. It starts on the clip
+// named first.
+func NewAnimator(sheet pixel.Picture, clips map[string]*Clip, first string) *Animator {
+	a := &Animator{
+		sheet:  sheet,
+		clips:  clips,
+		sprite: pixel.NewSprite(nil, pixel.Rect{}),
+	}
+	a.Play(first)
+	return a
+}
+
+// Play switches to the named clip, starting it from its first frame. Playing
+// the clip that is already playing does nothing.
+func (a *Animator) Play(name string) {
+	if name == a.name {
+		return
+	}
+	if _, ok := a.clips[name]; !ok {
+		panic("graphics: no animation clip named " + strconv.Quote(name))
+	}
+	a.name = name
+	a.time = 0
+	a.ended = false
+}
+
+// Clip returns the name of the clip being played.
+func (a *Animator) Clip() string { return a.name }
+
+// Ended reports whether a clip that doesn't loop has reached its end.
+func (a *Animator) Ended() bool { return a.ended }
+
+// Update advances the clip by dt seconds. If that takes a clip that doesn't
+// loop to its end, it returns the clip's name, once.
+func (a *Animator) Update(dt float64) (ended string) {
+	if a.ended {
+		return ""
+	}
+	clip := a.clips[a.name]
+	a.time += dt
+	if clip.Loop || a.time < clip.Length() {
+		return ""
+	}
+	a.ended = true
+	return a.name
+}
+
+// Frame returns the frame to show right now.
+func (a *Animator) Frame() Frame {
+	clip := a.clips[a.name]
+	return clip.Frames[clip.frameAt(a.time)]
+}
+
+// Sprite returns a sprite showing the current frame. Draw it scaled by the
+// frame's density and moved by its offset, or use Draw, to keep the frame's
+// size and pivot.
+func (a *Animator) Sprite() *pixel.Sprite {
+	a.sprite.Set(a.sheet, a.Frame().Rect)
+	return a.sprite
+}
+
+// Draw draws the current frame at its drawn size, with its pivot at the
+// origin transformed by m.
+func (a *Animator) Draw(t pixel.Target, m pixel.Matrix) {
+	frame := a.Frame()
+	a.sprite.Set(a.sheet, frame.Rect)
+	a.sprite.Draw(t, pixel.IM.Scaled(pixel.ZV, frame.scale()).Moved(frame.Offset).Chained(m))
+}
+
+// Clips returns the clips the animator plays, by name.
+func (a *Animator) Clips() map[string]*Clip { return a.clips }
+
+// Sheet returns the picture the clips are cut from.
+func (a *Animator) Sheet() pixel.Picture { return a.sheet }
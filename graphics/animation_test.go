@@ -0,0 +1,92 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// clip returns a clip of frames lasting the given durations, with frame i
+// at x = i in the sheet.
+func clip(fps float64, loop bool, durations ...float64) *Clip {
+	c := &Clip{FPS: fps, Loop: loop}
+	for i, d := range durations {
+		c.Frames = append(c.Frames, Frame{Rect: pixel.R(float64(i), 0, float64(i+1), 1), Duration: d})
+	}
+	return c
+}
+
+func TestClipFrameAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		clip  *Clip
+		times []float64
+		want  []int
+	}{
+		{"fps", clip(4, false, 0, 0, 0), []float64{0, 0.1, 0.3, 0.6}, []int{0, 0, 1, 2}},
+		{"one shot stops on its last frame", clip(4, false, 0, 0, 0), []float64{0.8, 10}, []int{2, 2}},
+		{"loop wraps", clip(4, true, 0, 0, 0), []float64{0.7, 0.8, 1.1, 10.1}, []int{2, 0, 1, 1}},
+		{"per frame durations", clip(4, false, 0.5, 0, 0.125), []float64{0.4, 0.6, 0.8, 2}, []int{0, 1, 2, 2}},
+		{"looping durations", clip(4, true, 0.5, 0, 0.125), []float64{0.9, 1.4, 1.7}, []int{0, 1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, at := range test.times {
+				if got := test.clip.frameAt(at); got != test.want[i] {
+					t.Errorf("frame at %vs is %d, want %d", at, got, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClipLength(t *testing.T) {
+	if got, want := clip(4, false, 0.5, 0, 0.125).Length(), 0.875; got != want {
+		t.Errorf("length is %v, want %v", got, want)
+	}
+}
+
+func TestAnimator(t *testing.T) {
+	clips := map[string]*Clip{
+		"Forward": clip(4, true, 0, 0),
+		"Wipeout": clip(4, false, 0, 0, 0),
+	}
+	a := NewAnimator(pixel.MakePictureData(pixel.R(0, 0, 3, 1)), clips, "Forward")
+
+	if ended := a.Update(1.1); ended != "" || a.Ended() {
+		t.Error("a looping clip ended")
+	}
+	if got := a.Frame().Rect.Min.X; got != 0 {
+		t.Errorf("looping clip is on frame %v, want 0", got)
+	}
+
+	a.Play("Wipeout")
+	if a.Clip() != "Wipeout" {
+		t.Errorf("playing %q, want Wipeout", a.Clip())
+	}
+	if ended := a.Update(0.3); ended != "" || a.Ended() {
+		t.Error("clip ended halfway through")
+	}
+	if got := a.Frame().Rect.Min.X; got != 1 {
+		t.Errorf("clip is on frame %v, want 1", got)
+	}
+	if ended := a.Update(0.5); ended != "Wipeout" || !a.Ended() {
+		t.Errorf("Update reported %q ending, want Wipeout", ended)
+	}
+	if ended := a.Update(0.5); ended != "" {
+		t.Errorf("Update reported %q ending again", ended)
+	}
+	if got := a.Frame().Rect.Min.X; got != 2 {
+		t.Errorf("ended clip is on frame %v, want its last, 2", got)
+	}
+
+	// Playing the same clip again doesn't restart it.
+	a.Play("Wipeout")
+	if !a.Ended() {
+		t.Error("playing the clip again restarted it")
+	}
+	a.Play("Forward")
+	if a.Ended() || a.Frame().Rect.Min.X != 0 {
+		t.Error("switching clips didn't start the new one from its first frame")
+	}
+}
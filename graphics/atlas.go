@@ -0,0 +1,118 @@
+package graphics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/faiface/pixel"
+)
+
+// atlasPadding is the number of transparent pixels kept between images in an
+// atlas, so that filtering never samples a neighbouring image.
+const atlasPadding = 2
+
+// Atlas is a single picture that several images are packed into. Sprites
+// cut from an atlas share one texture, so they can all be drawn through one
+// pixel.Batch.
+type Atlas struct {
+	pic   *pixel.PictureData
+	rects map[string]pixel.Rect
+	// moves are how far each image moved from where it was in its own
+	// picture to where it is in the atlas.
+	moves map[string]pixel.Vec
+}
+
+// PackAtlas packs the named pictures into an atlas. Images are placed on
+// shelves, tallest first, in an atlas as wide as the next power of two that
+// fits them.
+func PackAtlas(pics map[string]pixel.Picture) *Atlas {
+	names := make([]string, 0, len(pics))
+	var area, widest float64
+	for name, pic := range pics {
+		names = append(names, name)
+		b := pic.Bounds()
+		area += (b.W() + atlasPadding) * (b.H() + atlasPadding)
+		widest = math.Max(widest, b.W()+atlasPadding)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		hi, hj := pics[names[i]].Bounds().H(), pics[names[j]].Bounds().H()
+		if hi != hj {
+			return hi > hj
+		}
+		return names[i] < names[j]
+	})
+
+	width := 1.0
+	for width < widest || width < math.Sqrt(area) {
+		width *= 2
+	}
+
+	// Lay the images out first so that the atlas can be allocated once.
+	a := &Atlas{
+		rects: make(map[string]pixel.Rect, len(pics)),
+		moves: make(map[string]pixel.Vec, len(pics)),
+	}
+	var x, y, shelf float64
+	for _, name := range names {
+		b := pics[name].Bounds()
+		if x+b.W() > width {
+			x, y, shelf = 0, y+shelf, 0
+		}
+		min := pixel.V(math.Floor(x), math.Floor(y))
+		a.rects[name] = pixel.Rect{Min: min, Max: min.Add(b.Size())}
+		a.moves[name] = min.Sub(b.Min)
+		x += b.W() + atlasPadding
+		shelf = math.Max(shelf, b.H()+atlasPadding)
+	}
+
+	a.pic = pixel.MakePictureData(pixel.R(0, 0, width, y+shelf))
+	for _, name := range names {
+		src := pixel.PictureDataFromPicture(pics[name])
+		dst := a.rects[name]
+		w, h := int(dst.W()), int(dst.H())
+		for row := 0; row < h; row++ {
+			from := src.Index(src.Rect.Min.Add(pixel.V(0, float64(row))))
+			to := a.pic.Index(dst.Min.Add(pixel.V(0, float64(row))))
+			copy(a.pic.Pix[to:to+w], src.Pix[from:from+w])
+		}
+	}
+	return a
+}
+
+// Picture returns the packed picture.
+func (a *Atlas) Picture() pixel.Picture { return a.pic }
+
+// Rect returns where the named image is in the atlas.
+func (a *Atlas) Rect(name string) pixel.Rect {
+	r, ok := a.rects[name]
+	if !ok {
+		panic("graphics: no image in the atlas named " + strconv.Quote(name))
+	}
+	return r
+}
+
+// Sprite returns a sprite showing the whole of the named image.
+func (a *Atlas) Sprite(name string) *pixel.Sprite {
+	return pixel.NewSprite(a.pic, a.Rect(name))
+}
+
+// Clips returns copies of clips cut from the named image, with their frames
+// moved to where the image is in the atlas.
+func (a *Atlas) Clips(name string, clips map[string]*Clip) map[string]*Clip {
+	move, ok := a.moves[name]
+	if !ok {
+		panic("graphics: no image in the atlas named " + strconv.Quote(name))
+	}
+	moved := make(map[string]*Clip, len(clips))
+	for clipName, clip := range clips {
+		c := *clip
+		c.Frames = make([]Frame, len(clip.Frames))
+		for i, f := range clip.Frames {
+			f.Rect = f.Rect.Moved(move)
+			c.Frames[i] = f
+		}
+		moved[clipName] = &c
+	}
+	return moved
+}
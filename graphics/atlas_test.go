@@ -0,0 +1,59 @@
+package graphics
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// solid returns a w by h picture filled with c.
+func solid(w, h float64, c color.RGBA) *pixel.PictureData {
+	pic := pixel.MakePictureData(pixel.R(0, 0, w, h))
+	for i := range pic.Pix {
+		pic.Pix[i] = c
+	}
+	return pic
+}
+
+func TestPackAtlas(t *testing.T) {
+	colors := map[string]color.RGBA{
+		"player":    {255, 0, 0, 255},
+		"obstacles": {0, 255, 0, 255},
+		"logo":      {0, 0, 255, 255},
+	}
+	pics := map[string]pixel.Picture{
+		"player":    solid(1248, 200, colors["player"]),
+		"obstacles": solid(272, 270, colors["obstacles"]),
+		"logo":      solid(100, 100, colors["logo"]),
+	}
+	atlas := PackAtlas(pics)
+
+	for name, pic := range pics {
+		r := atlas.Rect(name)
+		if r.Size() != pic.Bounds().Size() {
+			t.Errorf("%s is %v in the atlas, want %v", name, r.Size(), pic.Bounds().Size())
+		}
+		if atlas.Picture().Bounds().Intersect(r) != r {
+			t.Errorf("%s at %v is outside the atlas %v", name, r, atlas.Picture().Bounds())
+		}
+		for other := range pics {
+			if other != name && r.Intersect(atlas.Rect(other)).Area() > 0 {
+				t.Errorf("%s at %v overlaps %s at %v", name, r, other, atlas.Rect(other))
+			}
+		}
+		for _, at := range []pixel.Vec{r.Min, r.Center(), r.Max.Sub(pixel.V(1, 1))} {
+			if got, want := atlas.Picture().(pixel.PictureColor).Color(at), pixel.ToRGBA(colors[name]); got != want {
+				t.Errorf("%s: color at %v is %v, want %v", name, at, got, want)
+			}
+		}
+	}
+
+	clips := atlas.Clips("obstacles", map[string]*Clip{
+		"HardDrive": {Frames: []Frame{{Rect: pixel.R(136, 0, 272, 270)}}},
+	})
+	want := pixel.R(136, 0, 272, 270).Moved(atlas.Rect("obstacles").Min)
+	if got := clips["HardDrive"].Frames[0].Rect; got != want {
+		t.Errorf("HardDrive frame is %v in the atlas, want %v", got, want)
+	}
+}
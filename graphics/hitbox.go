@@ -0,0 +1,190 @@
+package graphics
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Hitbox is the shape of a sprite used for collisions. Coordinates are
+// relative to the center of the sprite, the point sprites are drawn around.
+type Hitbox interface {
+	// Bounds returns the smallest rectangle containing the shape.
+	Bounds() pixel.Rect
+	// Contains reports whether the point p is inside the shape.
+	Contains(p pixel.Vec) bool
+}
+
+// Box is a rectangular hitbox.
+type Box pixel.Rect
+
+// CenteredBox returns a box of the given size centered on the sprite.
+func CenteredBox(w, h float64) Box {
+	return Box(pixel.R(-w/2, -h/2, w/2, h/2))
+}
+
+// Bounds implements Hitbox.
+func (b Box) Bounds() pixel.Rect { return pixel.Rect(b) }
+
+// Contains implements Hitbox.
+func (b Box) Contains(p pixel.Vec) bool { return pixel.Rect(b).Contains(p) }
+
+// Circle is a round hitbox.
+type Circle struct {
+	Center pixel.Vec
+	Radius float64
+}
+
+// Bounds implements Hitbox.
+func (c Circle) Bounds() pixel.Rect {
+	return pixel.R(c.Center.X-c.Radius, c.Center.Y-c.Radius, c.Center.X+c.Radius, c.Center.Y+c.Radius)
+}
+
+// Contains implements Hitbox.
+func (c Circle) Contains(p pixel.Vec) bool {
+	return p.Sub(c.Center).Len() <= c.Radius
+}
+
+// Mask is a hitbox made of the opaque pixels of a sprite, so transparent
+// padding around the artwork never collides.
+type Mask struct {
+	bounds pixel.Rect
+	origin pixel.Vec
+	w, h   int
+	solid  []bool
+}
+
+// NewMask builds a mask from the pixels of frame within pic whose alpha is
+// above threshold.
+func NewMask(pic pixel.Picture, frame pixel.Rect, threshold uint8) *Mask {
+	data := pixel.PictureDataFromPicture(pic)
+	m := &Mask{
+		origin: frame.Min.Sub(frame.Center()),
+		w:      int(frame.W()),
+		h:      int(frame.H()),
+	}
+	m.solid = make([]bool, m.w*m.h)
+
+	first := true
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			at := frame.Min.Add(pixel.V(float64(x), float64(y)))
+			if data.Color(at).A*255 <= float64(threshold) {
+				continue
+			}
+			m.solid[y*m.w+x] = true
+
+			cell := pixel.R(float64(x), float64(y), float64(x+1), float64(y+1)).Moved(m.origin)
+			if first {
+				m.bounds = cell
+				first = false
+			} else {
+				m.bounds = m.bounds.Union(cell)
+			}
+		}
+	}
+	return m
+}
+
+// Moved returns the mask shifted by delta, such as by a frame's offset from
+// its pivot. The copy shares its pixels with m.
+func (m *Mask) Moved(delta pixel.Vec) *Mask {
+	moved := *m
+	moved.origin = m.origin.Add(delta)
+	moved.bounds = m.bounds.Moved(delta)
+	return &moved
+}
+
+// Bounds implements Hitbox. It only covers the opaque pixels of the frame.
+func (m *Mask) Bounds() pixel.Rect { return m.bounds }
+
+// Contains implements Hitbox.
+func (m *Mask) Contains(p pixel.Vec) bool {
+	p = p.Sub(m.origin)
+	x, y := int(math.Floor(p.X)), int(math.Floor(p.Y))
+	if x < 0 || y < 0 || x >= m.w || y >= m.h {
+		return false
+	}
+	return m.solid[y*m.w+x]
+}
+
+// Overlaps reports whether hitbox a placed at aPos overlaps hitbox b placed
+// at bPos.
+func Overlaps(a Hitbox, aPos pixel.Vec, b Hitbox, bPos pixel.Vec) bool {
+	aBounds, bBounds := a.Bounds().Moved(aPos), b.Bounds().Moved(bPos)
+	shared := aBounds.Intersect(bBounds)
+	if shared == (pixel.Rect{}) {
+		return false
+	}
+
+	switch a := a.(type) {
+	case Box:
+		switch b := b.(type) {
+		case Box:
+			return true
+		case Circle:
+			return circleOverlapsRect(b, bPos, aBounds)
+		}
+	case Circle:
+		switch b := b.(type) {
+		case Box:
+			return circleOverlapsRect(a, aPos, bBounds)
+		case Circle:
+			return aPos.Add(a.Center).Sub(bPos.Add(b.Center)).Len() <= a.Radius+b.Radius
+		}
+	}
+
+	// At least one of the shapes is a mask, so test its solid pixels in
+	// the shared area against the other shape.
+	if a, ok := a.(*Mask); ok {
+		return a.overlaps(aPos, shared, b, bPos)
+	}
+	if b, ok := b.(*Mask); ok {
+		return b.overlaps(bPos, shared, a, aPos)
+	}
+	for y := math.Floor(shared.Min.Y); y < shared.Max.Y; y++ {
+		for x := math.Floor(shared.Min.X); x < shared.Max.X; x++ {
+			p := pixel.V(x+0.5, y+0.5)
+			if a.Contains(p.Sub(aPos)) && b.Contains(p.Sub(bPos)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlaps reports whether any solid pixel of m, placed at pos, that is
+// within area is inside other placed at otherPos. Only the pixels of m in
+// area are scanned.
+func (m *Mask) overlaps(pos pixel.Vec, area pixel.Rect, other Hitbox, otherPos pixel.Vec) bool {
+	corner := pos.Add(m.origin)
+	area = area.Moved(corner.Scaled(-1))
+	x0, x1 := clampInt(math.Floor(area.Min.X), m.w), clampInt(math.Ceil(area.Max.X), m.w)
+	y0, y1 := clampInt(math.Floor(area.Min.Y), m.h), clampInt(math.Ceil(area.Max.Y), m.h)
+
+	// Pixel centers of m, in the space of other.
+	offset := corner.Sub(otherPos).Add(pixel.V(0.5, 0.5))
+	for y := y0; y < y1; y++ {
+		row := m.solid[y*m.w : (y+1)*m.w]
+		for x := x0; x < x1; x++ {
+			if row[x] && other.Contains(offset.Add(pixel.V(float64(x), float64(y)))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clampInt converts v to an int between 0 and max.
+func clampInt(v float64, max int) int {
+	return int(pixel.Clamp(v, 0, float64(max)))
+}
+
+func circleOverlapsRect(c Circle, pos pixel.Vec, r pixel.Rect) bool {
+	center := pos.Add(c.Center)
+	closest := pixel.V(
+		pixel.Clamp(center.X, r.Min.X, r.Max.X),
+		pixel.Clamp(center.Y, r.Min.Y, r.Max.Y),
+	)
+	return center.Sub(closest).Len() <= c.Radius
+}
@@ -0,0 +1,110 @@
+package graphics
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// maskPicture returns a w by h picture that is transparent except for the
+// given pixels, which have the given alpha.
+func maskPicture(w, h int, alpha uint8, pixels ...[2]int) *pixel.PictureData {
+	pic := pixel.MakePictureData(pixel.R(0, 0, float64(w), float64(h)))
+	for _, p := range pixels {
+		pic.Pix[p[1]*pic.Stride+p[0]] = color.RGBA{A: alpha}
+	}
+	return pic
+}
+
+func TestNewMask(t *testing.T) {
+	pic := maskPicture(10, 10, 255, [2]int{0, 0}, [2]int{9, 9})
+	m := NewMask(pic, pic.Bounds(), 64)
+	if want := pixel.R(-5, -5, 5, 5); m.Bounds() != want {
+		t.Errorf("bounds are %v, want %v", m.Bounds(), want)
+	}
+	for _, test := range []struct {
+		p    pixel.Vec
+		want bool
+	}{
+		{pixel.V(-4.5, -4.5), true},
+		{pixel.V(4.5, 4.5), true},
+		{pixel.V(0, 0), false},
+		{pixel.V(-5.5, -4.5), false},
+	} {
+		if got := m.Contains(test.p); got != test.want {
+			t.Errorf("contains %v = %v, want %v", test.p, got, test.want)
+		}
+	}
+
+	moved := m.Moved(pixel.V(10, -20))
+	if want := pixel.R(5, -25, 15, -15); moved.Bounds() != want {
+		t.Errorf("moved bounds are %v, want %v", moved.Bounds(), want)
+	}
+	if !moved.Contains(pixel.V(5.5, -24.5)) || moved.Contains(pixel.V(-4.5, -4.5)) {
+		t.Error("the moved mask's pixels didn't move with it")
+	}
+	if m.Bounds() != pixel.R(-5, -5, 5, 5) {
+		t.Error("moving a mask changed the original")
+	}
+}
+
+func TestNewMaskThreshold(t *testing.T) {
+	for _, test := range []struct {
+		alpha uint8
+		solid bool
+	}{
+		{63, false},
+		{64, false},
+		{65, true},
+		{255, true},
+	} {
+		pic := maskPicture(2, 2, test.alpha, [2]int{1, 1})
+		m := NewMask(pic, pic.Bounds(), 64)
+		if got := m.Contains(pixel.V(0.5, 0.5)); got != test.solid {
+			t.Errorf("alpha %d: solid = %v, want %v", test.alpha, got, test.solid)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	// diagonal only has its bottom left and top right pixels, so its
+	// bounds are mostly transparent.
+	diagonalPic := maskPicture(10, 10, 255, [2]int{0, 0}, [2]int{9, 9})
+	diagonal := NewMask(diagonalPic, diagonalPic.Bounds(), 64)
+	box := CenteredBox(10, 10)
+	circle := Circle{Radius: 5}
+
+	tests := []struct {
+		name string
+		a    Hitbox
+		aPos pixel.Vec
+		b    Hitbox
+		bPos pixel.Vec
+		want bool
+	}{
+		{"boxes apart", box, pixel.ZV, box, pixel.V(11, 0), false},
+		{"boxes overlap", box, pixel.ZV, box, pixel.V(9, 9), true},
+		{"circles apart", circle, pixel.ZV, circle, pixel.V(8, 8), false},
+		{"circles overlap", circle, pixel.ZV, circle, pixel.V(6, 6), true},
+		{"circle off a box corner", circle, pixel.V(9, 9), box, pixel.ZV, false},
+		{"circle on a box edge", box, pixel.ZV, circle, pixel.V(9, 0), true},
+		{"masks overlap in transparent pixels", diagonal, pixel.ZV, diagonal, pixel.V(5, 0), false},
+		{"masks overlap in opaque pixels", diagonal, pixel.ZV, diagonal, pixel.V(9, 9), true},
+		{"box in a mask's transparent middle", diagonal, pixel.ZV, CenteredBox(4, 4), pixel.ZV, false},
+		{"box on a mask's opaque corner", CenteredBox(4, 4), pixel.V(-5, -5), diagonal, pixel.ZV, true},
+		{"circle in a mask's transparent middle", circle, pixel.ZV, diagonal, pixel.ZV, false},
+		{"circle on a mask's opaque corner", diagonal, pixel.ZV, circle, pixel.V(7, 7), true},
+		{"moved masks overlap", diagonal.Moved(pixel.V(9, 9)), pixel.ZV, diagonal, pixel.ZV, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Overlaps(test.a, test.aPos, test.b, test.bPos); got != test.want {
+				t.Errorf("overlaps = %v, want %v", got, test.want)
+			}
+			if got := Overlaps(test.b, test.bPos, test.a, test.aPos); got != test.want {
+				t.Errorf("overlaps the other way round = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
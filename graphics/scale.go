@@ -0,0 +1,38 @@
+package graphics
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+)
+
+// maxVariant is the highest resolution variant assets are looked up in.
+const maxVariant = 3
+
+// ScaledPath returns the variant of an asset best suited to a screen with
+// the given content scale, and how many times the resolution of the asset
+// itself the variant is. Variants are named by a suffix before the
+// extension, player@2x.png for player.png, and are looked for in fsys next
+// to the asset and in an hd directory beside it. The variant at or just above the
+// content scale is preferred, falling back to smaller ones and finally the
+// asset itself.
+func ScaledPath(fsys fs.FS, name string, scale float64) (string, float64) {
+	want := int(math.Ceil(scale - 0.01))
+	if want > maxVariant {
+		want = maxVariant
+	}
+	dir, file := path.Split(name)
+	ext := path.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+	for variant := want; variant > 1; variant-- {
+		scaled := fmt.Sprintf("%s@%dx%s", base, variant, ext)
+		for _, candidate := range []string{path.Join(dir, scaled), path.Join(dir, "hd", scaled)} {
+			if _, err := fs.Stat(fsys, candidate); err == nil {
+				return candidate, float64(variant)
+			}
+		}
+	}
+	return name, 1
+}
@@ -0,0 +1,450 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+)
+
+// LoadSheet loads a sprite sheet from fsys, described by a JSON file in the
+// format exported by Aseprite and TexturePacker, in either its array or hash
+// form:
+//
+//	{
+//	  "frames": [
+//	    {
+//	      "frame": {"x": 0, "y": 0, "w": 64, "h": 96},
+//	      "trimmed": true,
+//	      "spriteSourceSize": {"x": 4, "y": 2, "w": 64, "h": 96},
+//	      "sourceSize": {"w": 72, "h": 100},
+//	      "pivot": {"x": 0.5, "y": 1},
+//	      "duration": 100
+//	    }
+//	  ],
+//	  "meta": {
+//	    "image": "player.png",
+//	    "frameTags": [{"name": "Run", "from": 0, "to": 3, "direction": "forward"}]
+//	  }
+//	}
+//
+// The image is found relative to the JSON file. Frame rectangles are in
+// pixels from the top left of the image, so frames can be packed in grids or
+// any other layout. Durations are in milliseconds. Every tag becomes a clip.
+// Tags loop unless they have a "repeat" count, in which case they play that
+// many times and stop.
+//
+// Sheets of equally sized frames can give a grid in the meta instead of
+// listing frames, which are then numbered left to right, top to bottom:
+//
+//	"meta": {"image": "player.png", "grid": {"w": 64, "h": 96, "count": 8}}
+//
+// Errors point at the line of the file the problem is on.
+func LoadSheet(fsys fs.FS, name string) (sheet pixel.Picture, clips map[string]*Clip, err error) {
+	return LoadScaledSheet(fsys, name, 1)
+}
+
+// LoadScaledSheet loads a sprite sheet like LoadSheet, but with the variant
+// of its image best suited to the content scale, see ScaledPath. The
+// descriptor always gives sizes and positions in pixels of the original
+// image. The frames of a variant are scaled up to match, and record their
+// density so that they are drawn at the original size.
+func LoadScaledSheet(fsys fs.FS, name string, scale float64) (sheet pixel.Picture, clips map[string]*Clip, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error loading sprite sheet")
+		}
+	}()
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	l := &sheetLoader{path: name, data: data}
+
+	var desc sheetDesc
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return nil, nil, l.jsonError(err)
+	}
+	if l.positions, err = valuePositions(data); err != nil {
+		return nil, nil, l.jsonError(err)
+	}
+
+	if desc.Meta.Image == "" {
+		return nil, nil, l.errorf("meta.image", "no image given")
+	}
+	image, density := ScaledPath(fsys, path.Join(path.Dir(name), desc.Meta.Image), scale)
+	sheet, err = LoadPicture(fsys, image)
+	if err != nil {
+		return nil, nil, l.errorf("meta.image", "%v", err)
+	}
+
+	// Work in pixels of the original image, and scale the frames to the
+	// variant once they are cut.
+	bounds := pixel.Rect{Max: sheet.Bounds().Size().Scaled(1 / density)}
+	if size := desc.Meta.Size; size != nil && (size.W != bounds.W() || size.H != bounds.H()) {
+		return nil, nil, l.errorf("meta.size", "the sheet is %vx%v, so %s should be %vx%v, but it is %vx%v",
+			size.W, size.H, image, size.W*density, size.H*density, sheet.Bounds().W(), sheet.Bounds().H())
+	}
+	frames, err := l.frames(desc, bounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range frames {
+		frames[i].Rect = pixel.Rect{
+			Min: frames[i].Rect.Min.Scaled(density),
+			Max: frames[i].Rect.Max.Scaled(density),
+		}.Moved(sheet.Bounds().Min)
+		frames[i].Density = density
+	}
+	clips, err = l.clips(desc, frames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sheet, clips, nil
+}
+
+type sheetDesc struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		Image string `json:"image"`
+		Size  *struct {
+			W, H float64
+		} `json:"size"`
+		Grid      *sheetGrid `json:"grid"`
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+			Repeat    string `json:"repeat"`
+		} `json:"frameTags"`
+	} `json:"meta"`
+}
+
+// sheetGrid lays out frames of the same size in rows, with margin pixels
+// around the sheet and spacing pixels between frames.
+type sheetGrid struct {
+	W        int     `json:"w"`
+	H        int     `json:"h"`
+	Count    int     `json:"count"`
+	Margin   int     `json:"margin"`
+	Spacing  int     `json:"spacing"`
+	Duration float64 `json:"duration"`
+}
+
+type sheetRect struct {
+	X, Y, W, H float64
+}
+
+type sheetFrame struct {
+	Frame            sheetRect `json:"frame"`
+	Rotated          bool      `json:"rotated"`
+	Trimmed          bool      `json:"trimmed"`
+	SpriteSourceSize sheetRect `json:"spriteSourceSize"`
+	SourceSize       struct {
+		W, H float64
+	} `json:"sourceSize"`
+	Pivot *struct {
+		X, Y float64
+	} `json:"pivot"`
+	Duration float64 `json:"duration"`
+}
+
+// sheetLoader holds what's needed to point errors at lines of the file.
+type sheetLoader struct {
+	path      string
+	data      []byte
+	positions map[string]int64
+}
+
+// SheetError is a problem with a sprite sheet description.
+type SheetError struct {
+	Path         string
+	Line, Column int
+	Err          error
+}
+
+func (e *SheetError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+}
+
+// errorf reports an error at the value with the given path, such as
+// "frames[3].frame" or "meta.frameTags[0]".
+func (l *sheetLoader) errorf(at, format string, args ...interface{}) error {
+	err := &SheetError{Path: l.path, Err: errors.Errorf(at+": "+format, args...)}
+	if offset, ok := l.positions["."+at]; ok {
+		err.Line, err.Column = lineColumn(l.data, offset)
+	}
+	return err
+}
+
+// jsonError points a JSON decoding error at the line it happened on.
+func (l *sheetLoader) jsonError(err error) error {
+	var offset int64 = -1
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	}
+	sheetErr := &SheetError{Path: l.path, Err: err}
+	if offset >= 0 {
+		sheetErr.Line, sheetErr.Column = lineColumn(l.data, offset)
+	}
+	return sheetErr
+}
+
+// frames decodes the frames of the sheet, from a list, a hash or a grid.
+func (l *sheetLoader) frames(desc sheetDesc, bounds pixel.Rect) ([]Frame, error) {
+	grid := desc.Meta.Grid
+	raw := bytes.TrimSpace(desc.Frames)
+	hasFrames := len(raw) > 0 && !bytes.Equal(raw, []byte("null"))
+	switch {
+	case grid != nil && hasFrames:
+		return nil, l.errorf("meta.grid", "give either frames or a grid, not both")
+	case grid != nil:
+		return l.gridFrames(grid, bounds)
+	case !hasFrames:
+		return nil, l.errorf("frames", "no frames given")
+	}
+
+	var descs []sheetFrame
+	var paths []string
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &descs); err != nil {
+			return nil, l.jsonError(err)
+		}
+		for i := range descs {
+			paths = append(paths, fmt.Sprintf("frames[%d]", i))
+		}
+	} else {
+		// The hash form keys frames by name. They are in the order they
+		// appear in the file, which the map loses.
+		var byName map[string]sheetFrame
+		if err := json.Unmarshal(raw, &byName); err != nil {
+			return nil, l.jsonError(err)
+		}
+		for name := range byName {
+			paths = append(paths, "frames."+name)
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			return l.positions["."+paths[i]] < l.positions["."+paths[j]]
+		})
+		for _, path := range paths {
+			descs = append(descs, byName[strings.TrimPrefix(path, "frames.")])
+		}
+	}
+
+	frames := make([]Frame, len(descs))
+	for i, d := range descs {
+		frame, err := l.frame(paths[i], d, bounds)
+		if err != nil {
+			return nil, err
+		}
+		frames[i] = frame
+	}
+	return frames, nil
+}
+
+// frame converts a frame from the top-left, y-down coordinates of the file
+// to a rectangle of the picture and the offset of its pivot.
+func (l *sheetLoader) frame(at string, d sheetFrame, bounds pixel.Rect) (Frame, error) {
+	r := d.Frame
+	if d.Rotated {
+		return Frame{}, l.errorf(at, "rotated frames are not supported")
+	}
+	if r.W <= 0 || r.H <= 0 {
+		return Frame{}, l.errorf(at+".frame", "frame has no size")
+	}
+	rect := pixel.R(r.X, bounds.H()-r.Y-r.H, r.X+r.W, bounds.H()-r.Y)
+	if rect.Min.X < 0 || rect.Min.Y < 0 || rect.Max.X > bounds.W() || rect.Max.Y > bounds.H() {
+		return Frame{}, l.errorf(at+".frame", "frame %vx%v at %v,%v is outside the %vx%v image",
+			r.W, r.H, r.X, r.Y, bounds.W(), bounds.H())
+	}
+	if d.Duration < 0 {
+		return Frame{}, l.errorf(at+".duration", "duration can't be negative")
+	}
+
+	// Where the trimmed frame sits within the untrimmed source, which is
+	// what the pivot is given relative to.
+	source := sheetRect{W: r.W, H: r.H}
+	placed := sheetRect{W: r.W, H: r.H}
+	if d.Trimmed {
+		source = sheetRect{W: d.SourceSize.W, H: d.SourceSize.H}
+		placed = d.SpriteSourceSize
+		if placed.X < 0 || placed.Y < 0 || placed.X+placed.W > source.W || placed.Y+placed.H > source.H {
+			return Frame{}, l.errorf(at+".spriteSourceSize", "trimmed frame doesn't fit in its %vx%v source", source.W, source.H)
+		}
+	}
+	pivot := pixel.V(0.5, 0.5)
+	if d.Pivot != nil {
+		pivot = pixel.V(d.Pivot.X, d.Pivot.Y)
+	}
+	center := pixel.V(placed.X+placed.W/2, placed.Y+placed.H/2)
+	offset := center.Sub(pixel.V(pivot.X*source.W, pivot.Y*source.H))
+
+	return Frame{
+		Rect:     rect,
+		Offset:   pixel.V(offset.X, -offset.Y),
+		Duration: d.Duration / 1000,
+	}, nil
+}
+
+// gridFrames numbers the cells of a grid left to right, top to bottom.
+func (l *sheetLoader) gridFrames(g *sheetGrid, bounds pixel.Rect) ([]Frame, error) {
+	if g.W <= 0 || g.H <= 0 {
+		return nil, l.errorf("meta.grid", "grid cells must have a width and height")
+	}
+	if g.Margin < 0 || g.Spacing < 0 {
+		return nil, l.errorf("meta.grid", "margin and spacing can't be negative")
+	}
+
+	columns := (int(bounds.W()) - 2*g.Margin + g.Spacing) / (g.W + g.Spacing)
+	rows := (int(bounds.H()) - 2*g.Margin + g.Spacing) / (g.H + g.Spacing)
+	count := g.Count
+	if count == 0 {
+		count = columns * rows
+	}
+	if count > columns*rows || columns <= 0 || rows <= 0 {
+		return nil, l.errorf("meta.grid", "%d frames of %dx%d don't fit in the %vx%v image",
+			count, g.W, g.H, bounds.W(), bounds.H())
+	}
+
+	frames := make([]Frame, count)
+	for i := range frames {
+		x := g.Margin + (i%columns)*(g.W+g.Spacing)
+		y := g.Margin + (i/columns)*(g.H+g.Spacing)
+		frames[i] = Frame{
+			Rect:     pixel.R(float64(x), bounds.H()-float64(y+g.H), float64(x+g.W), bounds.H()-float64(y)),
+			Duration: g.Duration / 1000,
+		}
+	}
+	return frames, nil
+}
+
+// clips turns the tags of the sheet into clips.
+func (l *sheetLoader) clips(desc sheetDesc, frames []Frame) (map[string]*Clip, error) {
+	clips := make(map[string]*Clip)
+	for i, tag := range desc.Meta.FrameTags {
+		at := fmt.Sprintf("meta.frameTags[%d]", i)
+		if tag.Name == "" {
+			return nil, l.errorf(at, "tag has no name")
+		}
+		if _, ok := clips[tag.Name]; ok {
+			return nil, l.errorf(at, "tag %q is defined twice", tag.Name)
+		}
+		if tag.From < 0 || tag.To < tag.From || tag.To >= len(frames) {
+			return nil, l.errorf(at, "tag %q: frames %d to %d are out of range for %d frames",
+				tag.Name, tag.From, tag.To, len(frames))
+		}
+
+		tagFrames := append([]Frame(nil), frames[tag.From:tag.To+1]...)
+		switch tag.Direction {
+		case "", "forward":
+		case "reverse":
+			reverseFrames(tagFrames)
+		case "pingpong":
+			back := append([]Frame(nil), tagFrames...)
+			reverseFrames(back)
+			if len(back) > 2 {
+				tagFrames = append(tagFrames, back[1:len(back)-1]...)
+			}
+		default:
+			return nil, l.errorf(at+".direction", "tag %q: unknown direction %q", tag.Name, tag.Direction)
+		}
+
+		clip := &Clip{Frames: tagFrames, FPS: defaultFPS, Loop: true}
+		if tag.Repeat != "" {
+			repeat, err := strconv.Atoi(tag.Repeat)
+			if err != nil || repeat <= 0 {
+				return nil, l.errorf(at+".repeat", "tag %q: repeat must be a positive number, got %q", tag.Name, tag.Repeat)
+			}
+			clip.Loop = false
+			for i := 1; i < repeat; i++ {
+				clip.Frames = append(clip.Frames, tagFrames...)
+			}
+		}
+		clips[tag.Name] = clip
+	}
+	return clips, nil
+}
+
+// defaultFPS is the frame rate of frames that don't say how long they last.
+const defaultFPS = 10
+
+func reverseFrames(frames []Frame) {
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+}
+
+// valuePositions maps the path of every value in a JSON document, such as
+// ".frames[2].frame", to the offset it starts at.
+func valuePositions(data []byte) (map[string]int64, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	positions := make(map[string]int64)
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		positions[path] = skipSeparators(data, dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(path + "." + key.(string)); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+		// Consume the closing delimiter.
+		_, err = dec.Token()
+		return err
+	}
+	return positions, walk("")
+}
+
+// skipSeparators moves offset past whitespace, colons and commas to where the
+// next value starts.
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// lineColumn converts an offset in data to a 1-based line and column.
+func lineColumn(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
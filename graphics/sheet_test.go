@@ -0,0 +1,194 @@
+package graphics
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/faiface/pixel"
+)
+
+// pngFile returns a file holding a transparent w by h PNG.
+func pngFile(t *testing.T, w, h int) *fstest.MapFile {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
+
+// sheetFS returns a filesystem holding a w by h sheet.png and the sheet.json
+// descriptor for it.
+func sheetFS(t *testing.T, w, h int, desc string) fstest.MapFS {
+	t.Helper()
+	return fstest.MapFS{
+		"sheet.png":  pngFile(t, w, h),
+		"sheet.json": {Data: []byte(desc)},
+	}
+}
+
+func TestLoadSheetGrid(t *testing.T) {
+	fsys := sheetFS(t, 64, 64, `{
+		"meta": {
+			"image": "sheet.png",
+			"grid": {"w": 32, "h": 32, "count": 3, "duration": 50},
+			"frameTags": [
+				{"name": "Bounce", "from": 0, "to": 2, "direction": "pingpong"},
+				{"name": "Once", "from": 1, "to": 2, "repeat": "2"}
+			]
+		}
+	}`)
+	_, clips, err := LoadSheet(fsys, "sheet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Frames are numbered from the top left, and pixel's y axis points up.
+	bounce := clips["Bounce"]
+	want := []pixel.Rect{
+		pixel.R(0, 32, 32, 64),
+		pixel.R(32, 32, 64, 64),
+		pixel.R(0, 0, 32, 32),
+		pixel.R(32, 32, 64, 64),
+	}
+	if len(bounce.Frames) != len(want) || !bounce.Loop {
+		t.Fatalf("Bounce has %d frames, loop %v, want %d looping", len(bounce.Frames), bounce.Loop, len(want))
+	}
+	for i, r := range want {
+		if bounce.Frames[i].Rect != r {
+			t.Errorf("Bounce frame %d is %v, want %v", i, bounce.Frames[i].Rect, r)
+		}
+	}
+	if got := bounce.Length(); got != 0.2 {
+		t.Errorf("Bounce lasts %v, want 0.2", got)
+	}
+
+	once := clips["Once"]
+	if len(once.Frames) != 4 || once.Loop {
+		t.Errorf("Once has %d frames, loop %v, want 4 played once", len(once.Frames), once.Loop)
+	}
+}
+
+func TestLoadSheetTrimmedPivot(t *testing.T) {
+	// A 20x30 frame trimmed out of a 40x40 source, 10 pixels from its left
+	// and 5 from its top, pivoted on the bottom middle of the source.
+	fsys := sheetFS(t, 20, 30, `{
+		"frames": {
+			"idle": {
+				"frame": {"x": 0, "y": 0, "w": 20, "h": 30},
+				"trimmed": true,
+				"spriteSourceSize": {"x": 10, "y": 5, "w": 20, "h": 30},
+				"sourceSize": {"w": 40, "h": 40},
+				"pivot": {"x": 0.5, "y": 1},
+				"duration": 100
+			}
+		},
+		"meta": {"image": "sheet.png", "frameTags": [{"name": "Idle", "from": 0, "to": 0}]}
+	}`)
+	_, clips, err := LoadSheet(fsys, "sheet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := clips["Idle"].Frames[0]
+	// The frame's center is at (20, 20) in the source and the pivot at
+	// (20, 40), so the center is drawn 20 pixels above the pivot.
+	if want := pixel.V(0, 20); frame.Offset != want {
+		t.Errorf("offset is %v, want %v", frame.Offset, want)
+	}
+	if frame.Duration != 0.1 {
+		t.Errorf("duration is %v, want 0.1", frame.Duration)
+	}
+}
+
+func TestLoadSheetErrorLine(t *testing.T) {
+	for _, test := range []struct {
+		name, desc, want string
+	}{
+		{
+			name: "frame outside image",
+			desc: `{
+  "frames": [
+    {"frame": {"x": 0, "y": 0, "w": 32, "h": 32}},
+    {"frame": {"x": 32, "y": 0, "w": 32, "h": 32}}
+  ],
+  "meta": {"image": "sheet.png"}
+}`,
+			want: "sheet.json:4:15: frames[1].frame",
+		},
+		{
+			name: "tag out of range",
+			desc: `{
+  "meta": {
+    "image": "sheet.png",
+    "grid": {"w": 32, "h": 32},
+    "frameTags": [
+      {"name": "Run", "from": 0, "to": 5}
+    ]
+  }
+}`,
+			want: "sheet.json:6:7: meta.frameTags[0]",
+		},
+		{
+			name: "wrong type",
+			desc: `{
+  "meta": {
+    "image": 7
+  }
+}`,
+			want: "sheet.json:3:15:",
+		},
+	} {
+		fsys := sheetFS(t, 32, 32, test.desc)
+		_, _, err := LoadSheet(fsys, "sheet.json")
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want it to contain %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestLoadScaledSheet(t *testing.T) {
+	fsys := sheetFS(t, 64, 32, `{
+		"meta": {
+			"image": "sheet.png",
+			"size": {"w": 64, "h": 32},
+			"grid": {"w": 32, "h": 32},
+			"frameTags": [{"name": "Run", "from": 0, "to": 1}]
+		}
+	}`)
+	fsys["hd/sheet@2x.png"] = pngFile(t, 128, 64)
+
+	for _, test := range []struct {
+		scale   float64
+		density float64
+		want    pixel.Rect
+	}{
+		{scale: 1, density: 1, want: pixel.R(32, 0, 64, 32)},
+		{scale: 1.5, density: 2, want: pixel.R(64, 0, 128, 64)},
+		// There is no @3x variant, so the @2x one is the closest.
+		{scale: 3, density: 2, want: pixel.R(64, 0, 128, 64)},
+	} {
+		sheet, clips, err := LoadScaledSheet(fsys, "sheet.json", test.scale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame := clips["Run"].Frames[1]
+		if frame.Rect != test.want || frame.Density != test.density {
+			t.Errorf("scale %v: got frame %v at density %v, want %v at %v", test.scale, frame.Rect, frame.Density, test.want, test.density)
+		}
+		if !sheet.Bounds().Contains(frame.Rect.Max.Sub(pixel.V(1, 1))) {
+			t.Errorf("scale %v: frame %v is outside the sheet %v", test.scale, frame.Rect, sheet.Bounds())
+		}
+	}
+
+	// A variant of the wrong size is named in the error, with its own size.
+	fsys["hd/sheet@2x.png"] = pngFile(t, 100, 64)
+	_, _, err := LoadScaledSheet(fsys, "sheet.json", 2)
+	want := "the sheet is 64x32, so hd/sheet@2x.png should be 128x64, but it is 100x64"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want it to contain %q", err, want)
+	}
+}
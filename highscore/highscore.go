@@ -0,0 +1,109 @@
+// Package highscore keeps the best runs in a file in the user's config
+// directory.
+package highscore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"storj.io/snoboard/atomicfile"
+)
+
+// Size is how many entries a table keeps.
+const Size = 10
+
+// Entry is a single run on the table.
+type Entry struct {
+	Name  string    `json:"name"`
+	Score float64   `json:"score"`
+	Level float64   `json:"level"`
+	Seed  int64     `json:"seed"`
+	Date  time.Time `json:"date"`
+}
+
+// Table is the list of best runs, highest score first.
+type Table struct {
+	Entries []Entry `json:"entries"`
+}
+
+// DefaultPath is where the table is kept unless told otherwise.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snoboard", "highscores.json"), nil
+}
+
+// Load reads a table. A missing file is an empty table.
+func Load(path string) (*Table, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Table{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading high scores")
+	}
+
+	table := &Table{}
+	if err := json.Unmarshal(data, table); err != nil {
+		return nil, errors.Wrapf(err, "error loading high scores from %q", path)
+	}
+	table.sort()
+	return table, nil
+}
+
+// Save writes the table, creating its directory if needed.
+func (t *Table) Save(path string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error saving high scores")
+		}
+	}()
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0644)
+}
+
+// Qualifies reports whether a run with the given score would make the table.
+func (t *Table) Qualifies(score float64) bool {
+	if score <= 0 {
+		return false
+	}
+	return len(t.Entries) < Size || score > t.Entries[len(t.Entries)-1].Score
+}
+
+// Add puts an entry on the table and returns its place, starting from 0, or
+// -1 if it didn't make the table.
+func (t *Table) Add(e Entry) int {
+	if !t.Qualifies(e.Score) {
+		return -1
+	}
+	place := sort.Search(len(t.Entries), func(i int) bool {
+		return t.Entries[i].Score < e.Score
+	})
+	t.Entries = append(t.Entries, Entry{})
+	copy(t.Entries[place+1:], t.Entries[place:])
+	t.Entries[place] = e
+	if len(t.Entries) > Size {
+		t.Entries = t.Entries[:Size]
+	}
+	return place
+}
+
+func (t *Table) sort() {
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		return t.Entries[i].Score > t.Entries[j].Score
+	})
+	if len(t.Entries) > Size {
+		t.Entries = t.Entries[:Size]
+	}
+}
@@ -0,0 +1,94 @@
+package highscore
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// table returns a table of entries named after their scores.
+func table(scores ...float64) *Table {
+	t := &Table{}
+	for _, score := range scores {
+		t.Entries = append(t.Entries, Entry{Name: name(score), Score: score})
+	}
+	return t
+}
+
+func name(score float64) string {
+	return fmt.Sprint(score)
+}
+
+func names(t *Table) []string {
+	var names []string
+	for _, e := range t.Entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestAdd(t *testing.T) {
+	full := []float64{100, 90, 80, 70, 60, 50, 40, 30, 20, 10}
+	tests := []struct {
+		name  string
+		table *Table
+		entry Entry
+		place int
+		want  []string
+	}{
+		{"empty table", table(), Entry{Name: "new", Score: 5}, 0, []string{"new"}},
+		{"zero score", table(), Entry{Name: "new", Score: 0}, -1, nil},
+		{"top", table(20, 10), Entry{Name: "new", Score: 30}, 0, []string{"new", name(20), name(10)}},
+		{"middle", table(20, 10), Entry{Name: "new", Score: 15}, 1, []string{name(20), "new", name(10)}},
+		{"tie goes after", table(20, 10), Entry{Name: "new", Score: 20}, 1, []string{name(20), "new", name(10)}},
+		{"room below the last", table(20, 10), Entry{Name: "new", Score: 5}, 2, []string{name(20), name(10), "new"}},
+		{"full table, below the last", table(full...), Entry{Name: "new", Score: 5}, -1, names(table(full...))},
+		{"full table, tying the last", table(full...), Entry{Name: "new", Score: 10}, -1, names(table(full...))},
+		{"full table drops the last", table(full...), Entry{Name: "new", Score: 55}, 5,
+			append(names(table(full[:5]...)), append([]string{"new"}, names(table(full[5:9]...))...)...)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.table.Qualifies(test.entry.Score); got != (test.place >= 0) {
+				t.Errorf("Qualifies is %v, want %v", got, test.place >= 0)
+			}
+			if place := test.table.Add(test.entry); place != test.place {
+				t.Errorf("added at %d, want %d", place, test.place)
+			}
+			if got := names(test.table); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("table is %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	missing, err := Load(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing.Entries) != 0 {
+		t.Errorf("missing file loaded as %v, want an empty table", missing.Entries)
+	}
+
+	path := filepath.Join(dir, "snoboard", "highscores.json")
+	want := table(30, 20, 20, 10)
+	want.Entries[0].Level = 3
+	want.Entries[0].Seed = 42
+	want.Entries[0].Date = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	want.Entries[2].Name = "second twenty"
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/font/basicfont"
+	"storj.io/snoboard/highscore"
+)
+
+const maxNameLength = 12
+
+var atlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+
+// nameEntry is a run that made the high-score table, waiting for the player
+// to type their name.
+type nameEntry struct {
+	entry highscore.Entry
+	name  string
+}
+
+func loadHighScores(scene *Scene) {
+	scene.highScores = &highscore.Table{}
+	path, err := highscore.DefaultPath()
+	if err != nil {
+		log.Printf("high scores won't be saved: %v", err)
+		return
+	}
+	table, err := highscore.Load(path)
+	if err != nil {
+		log.Printf("high scores won't be saved: %v", err)
+		return
+	}
+	scene.highScores = table
+	scene.highScoresPath = path
+}
+
+// newNameEntry asks for the player's name if the run that just ended made
+// the high-score table, and returns nil if it didn't.
+func newNameEntry(scene *Scene) *nameEntry {
+	world := scene.World
+	if scene.replay != nil || !scene.highScores.Qualifies(world.Score()) {
+		return nil
+	}
+	return &nameEntry{
+		entry: highscore.Entry{
+			Score: world.Score(),
+			Level: world.Level,
+			Seed:  world.Seed,
+			Date:  time.Now(),
+		},
+	}
+}
+
+// update handles typing the name. Once return is pressed it saves the entry
+// and reports true.
+func (e *nameEntry) update(scene *Scene) bool {
+	win := scene.Window
+	for _, r := range win.Typed() {
+		if r >= ' ' && r <= '~' && len(e.name) < maxNameLength {
+			e.name += string(r)
+		}
+	}
+	if win.Repeated(pixelgl.KeyBackspace) && len(e.name) > 0 {
+		e.name = e.name[:len(e.name)-1]
+	}
+	if !win.JustPressed(pixelgl.KeyEnter) {
+		return false
+	}
+
+	e.entry.Name = strings.TrimSpace(e.name)
+	if e.entry.Name == "" {
+		e.entry.Name = "anonymous"
+	}
+	scene.highScores.Add(e.entry)
+	if scene.highScoresPath != "" {
+		if err := scene.highScores.Save(scene.highScoresPath); err != nil {
+			log.Print(err)
+		}
+	}
+	return true
+}
+
+func (e *nameEntry) render(scene *Scene) {
+	txt := screenText(scene, pixel.V(100, 600))
+	fmt.Fprintln(txt, "NEW HIGH SCORE!")
+	fmt.Fprintf(txt, "Name: %s_\n", e.name)
+	fmt.Fprintln(txt, "Press return to save")
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 2))
+}
+
+// renderHighScores draws the high-score table.
+func renderHighScores(scene *Scene) {
+	txt := screenText(scene, pixel.V(60, 650))
+	fmt.Fprintln(txt, "HIGH SCORES")
+	fmt.Fprintln(txt)
+	for i, e := range scene.highScores.Entries {
+		fmt.Fprintf(txt, "%2d. %-12s %8.0f  level %-3v seed %-20d %s\n",
+			i+1, e.Name, e.Score, e.Level, e.Seed, e.Date.Format("2006-01-02"))
+	}
+	if len(scene.highScores.Entries) == 0 {
+		fmt.Fprintln(txt, "No runs yet")
+	}
+	fmt.Fprintln(txt)
+	fmt.Fprintln(txt, "Press return to go back")
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 2))
+}
@@ -0,0 +1,250 @@
+// Package input maps the keys the player presses to the actions they stand
+// for, and keeps the player's own choice of keys in a file in their config
+// directory.
+package input
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"storj.io/snoboard/atomicfile"
+)
+
+// Action is something the player does with the keyboard.
+type Action int
+
+// The actions, in the order they are listed to the player.
+const (
+	SteerLeft Action = iota
+	SteerRight
+	Jump
+	Restart
+	Pause
+)
+
+// Actions lists every action.
+var Actions = []Action{SteerLeft, SteerRight, Jump, Restart, Pause}
+
+var actionIDs = map[Action]string{
+	SteerLeft:  "steer_left",
+	SteerRight: "steer_right",
+	Jump:       "jump",
+	Restart:    "restart",
+	Pause:      "pause",
+}
+
+var actionNames = map[Action]string{
+	SteerLeft:  "Steer left",
+	SteerRight: "Steer right",
+	Jump:       "Jump",
+	Restart:    "Restart",
+	Pause:      "Pause",
+}
+
+// String returns the name of the action shown to the player.
+func (a Action) String() string {
+	if name, ok := actionNames[a]; ok {
+		return name
+	}
+	return "Action(" + strconv.Itoa(int(a)) + ")"
+}
+
+// MarshalText returns the name of the action in the bindings file.
+func (a Action) MarshalText() ([]byte, error) {
+	id, ok := actionIDs[a]
+	if !ok {
+		return nil, errors.Errorf("unknown action %d", int(a))
+	}
+	return []byte(id), nil
+}
+
+// UnmarshalText reads the name of an action in the bindings file.
+func (a *Action) UnmarshalText(text []byte) error {
+	for action, id := range actionIDs {
+		if id == string(text) {
+			*a = action
+			return nil
+		}
+	}
+	return errors.Errorf("unknown action %q", text)
+}
+
+// Key is a physical key, named the way pixelgl names its buttons, like
+// "Left", "Space" or "A". Keys are where they are on a US keyboard whatever
+// the layout, so "A" is the key labelled Q on an AZERTY keyboard.
+type Key string
+
+// Bindings are the keys that do each action. An action can have any number
+// of keys, and a key does at most one action.
+type Bindings map[Action][]Key
+
+// menuKeys are the keys that move through and choose from menus, and the
+// action each may still do. They can't do any other action, so that no
+// menu is left that can't be used. Escape leaves menus, which is what
+// pausing and resuming does too.
+var menuKeys = map[Key][]Action{
+	"Up":     nil,
+	"Down":   nil,
+	"Enter":  nil,
+	"Escape": {Pause},
+}
+
+// Default returns the keys the game ships with.
+func Default() Bindings {
+	return Bindings{
+		SteerLeft:  {"Left"},
+		SteerRight: {"Right"},
+		Jump:       {"Space"},
+		Restart:    {"R"},
+		Pause:      {"Escape", "P"},
+	}
+}
+
+// Action returns the action a key does.
+func (b Bindings) Action(key Key) (Action, bool) {
+	for action, keys := range b {
+		for _, k := range keys {
+			if k == key {
+				return action, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Bind adds a key to an action, taking it from any action it did before.
+// It refuses keys the menus need, and the last key of another action, which
+// would leave that action with no key at all.
+func (b Bindings) Bind(action Action, key Key) error {
+	if err := checkMenuKey(action, key); err != nil {
+		return err
+	}
+	if other, ok := b.Action(key); ok && other != action && len(b[other]) == 1 {
+		return errors.Errorf("%s is the only key for %s", key, other)
+	}
+	b.Unbind(key)
+	b[action] = append(b[action], key)
+	return nil
+}
+
+// checkMenuKey reports an error if key is a menu key that can't do action.
+func checkMenuKey(action Action, key Key) error {
+	allowed, ok := menuKeys[key]
+	if !ok {
+		return nil
+	}
+	for _, a := range allowed {
+		if a == action {
+			return nil
+		}
+	}
+	return errors.Errorf("%s is kept for the menus", key)
+}
+
+// Unbind stops a key doing anything.
+func (b Bindings) Unbind(key Key) {
+	for action, keys := range b {
+		kept := keys[:0]
+		for _, k := range keys {
+			if k != key {
+				kept = append(kept, k)
+			}
+		}
+		b[action] = kept
+	}
+}
+
+// Clone returns a copy of the bindings that can be changed on its own.
+func (b Bindings) Clone() Bindings {
+	clone := make(Bindings, len(b))
+	for action, keys := range b {
+		clone[action] = append([]Key(nil), keys...)
+	}
+	return clone
+}
+
+// DefaultPath is where the bindings are kept unless told otherwise.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snoboard", "bindings.json"), nil
+}
+
+// Load reads bindings. A missing file is the default bindings, and actions
+// missing from the file keep their default keys. Menu keys the file gives
+// to other actions are left out, and actions it leaves without any key get
+// back those of their default keys that are free. Each of these is
+// returned as a problem, while the rest of the file still applies.
+func Load(path string) (b Bindings, problems []error, err error) {
+	b = Default()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error loading key bindings")
+	}
+
+	var saved Bindings
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, nil, errors.Wrapf(err, "error loading key bindings from %q", path)
+	}
+	// Apply the whole file before checking it, so that keys can move
+	// between actions whatever order they are listed in.
+	for action := range saved {
+		b[action] = nil
+	}
+	for _, action := range Actions {
+		for _, key := range saved[action] {
+			b.Unbind(key)
+			b[action] = append(b[action], key)
+		}
+	}
+
+	for _, action := range Actions {
+		for _, key := range append([]Key(nil), b[action]...) {
+			if err := checkMenuKey(action, key); err != nil {
+				b.Unbind(key)
+				problems = append(problems, errors.Wrap(err, action.String()))
+			}
+		}
+	}
+	defaults := Default()
+	for _, action := range Actions {
+		if len(b[action]) > 0 {
+			continue
+		}
+		for _, key := range defaults[action] {
+			if _, taken := b.Action(key); !taken {
+				b[action] = append(b[action], key)
+			}
+		}
+		if len(b[action]) > 0 {
+			problems = append(problems, errors.Errorf("%s has no keys, so it keeps its default ones", action))
+		} else {
+			problems = append(problems, errors.Errorf("%s has no keys", action))
+		}
+	}
+	return b, problems, nil
+}
+
+// Save writes the bindings, creating their directory if needed.
+func (b Bindings) Save(path string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error saving key bindings")
+		}
+	}()
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0644)
+}
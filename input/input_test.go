@@ -0,0 +1,192 @@
+package input
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	b := Default()
+	for _, bind := range []struct {
+		action Action
+		key    Key
+	}{
+		{SteerLeft, "A"},
+		{Jump, "Left"},
+	} {
+		if err := b.Bind(bind.action, bind.key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Bindings{
+		SteerLeft:  {"A"},
+		SteerRight: {"Right"},
+		Jump:       {"Space", "Left"},
+		Restart:    {"R"},
+		Pause:      {"Escape", "P"},
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("got %v, want %v", b, want)
+	}
+	if action, ok := b.Action("Left"); !ok || action != Jump {
+		t.Errorf("Left does %v, %v, want Jump", action, ok)
+	}
+	if _, ok := b.Action("Up"); ok {
+		t.Error("Up does something, want nothing")
+	}
+}
+
+func TestBindRefused(t *testing.T) {
+	tests := []struct {
+		name   string
+		action Action
+		key    Key
+		err    string
+	}{
+		{"last key of another action", Jump, "R", "R is the only key for Restart"},
+		{"menu key", Jump, "Enter", "Enter is kept for the menus"},
+		{"escape for anything but pause", Restart, "Escape", "Escape is kept for the menus"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := Default()
+			err := b.Bind(test.action, test.key)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+			if !reflect.DeepEqual(b, Default()) {
+				t.Errorf("refused binding changed the bindings to %v", b)
+			}
+		})
+	}
+
+	// Escape can go back to pausing, and a key can move to its own action.
+	b := Default()
+	for _, key := range []Key{"Escape", "P"} {
+		if err := b.Bind(Pause, key); err != nil {
+			t.Errorf("binding %s to Pause: %v", key, err)
+		}
+	}
+	if err := b.Bind(Restart, "R"); err != nil {
+		t.Errorf("binding R to Restart again: %v", err)
+	}
+	if !reflect.DeepEqual(b, Default()) {
+		t.Errorf("got %v, want the defaults", b)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snoboard", "bindings.json")
+
+	b, problems, err := Load(path)
+	if err != nil || problems != nil {
+		t.Fatal(err, problems)
+	}
+	if !reflect.DeepEqual(b, Default()) {
+		t.Errorf("missing file loaded as %v, want the defaults", b)
+	}
+
+	if err := b.Bind(SteerLeft, "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Bind(SteerRight, "D"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, problems, err := Load(path)
+	if err != nil || problems != nil {
+		t.Fatal(err, problems)
+	}
+	if !reflect.DeepEqual(loaded, b) {
+		t.Errorf("loaded %v, want %v", loaded, b)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"fly": ["F"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Load(path); err == nil {
+		t.Error("loaded an unknown action without an error")
+	}
+}
+
+func TestLoadProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		want     Bindings
+		problems []string
+	}{
+		{
+			name: "actions the file leaves out keep their defaults",
+			file: `{"jump": ["W", "P"]}`,
+			want: Bindings{Jump: {"W", "P"}, Pause: {"Escape"}},
+		},
+		{
+			name: "keys move between actions",
+			file: `{"restart": ["Space"], "jump": ["R"]}`,
+			want: Bindings{Jump: {"R"}, Restart: {"Space"}},
+		},
+		{
+			name: "escape can pause",
+			file: `{"pause": ["Escape"]}`,
+			want: Bindings{Pause: {"Escape"}},
+		},
+		{
+			name:     "menu key",
+			file:     `{"jump": ["Up", "W"]}`,
+			want:     Bindings{Jump: {"W"}},
+			problems: []string{"Jump: Up is kept for the menus"},
+		},
+		{
+			name:     "only a menu key",
+			file:     `{"restart": ["Enter"]}`,
+			want:     Bindings{},
+			problems: []string{"Restart: Enter is kept for the menus", "Restart has no keys, so it keeps its default ones"},
+		},
+		{
+			name:     "no keys",
+			file:     `{"jump": []}`,
+			want:     Bindings{},
+			problems: []string{"Jump has no keys, so it keeps its default ones"},
+		},
+		{
+			name:     "last key taken",
+			file:     `{"jump": ["R"]}`,
+			want:     Bindings{Jump: {"R"}, Restart: {}},
+			problems: []string{"Restart has no keys"},
+		},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strconv.Itoa(i)+".json")
+			if err := ioutil.WriteFile(path, []byte(test.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			b, problems, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Default()
+			for action, keys := range test.want {
+				want[action] = keys
+			}
+			if !reflect.DeepEqual(b, want) {
+				t.Errorf("loaded %v, want %v", b, want)
+			}
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.Error())
+			}
+			if !reflect.DeepEqual(got, test.problems) {
+				t.Errorf("got problems %q, want %q", got, test.problems)
+			}
+		})
+	}
+}
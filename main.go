@@ -1,332 +1,507 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	_ "image/png"
+	"log"
 	"math"
-	"math/rand"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
-	"github.com/faiface/pixel/text"
 	"golang.org/x/image/colornames"
-	"golang.org/x/image/font/basicfont"
+	"storj.io/snoboard/assets"
 	"storj.io/snoboard/audio"
+	"storj.io/snoboard/audio/device"
+	"storj.io/snoboard/config"
+	"storj.io/snoboard/game"
 	"storj.io/snoboard/graphics"
+	"storj.io/snoboard/highscore"
+	"storj.io/snoboard/input"
+	"storj.io/snoboard/watch"
 )
 
-const (
-	windowWidth  = 1024
-	windowHeight = 768
-	speed        = 300
-	jumpSpeed    = 500
-	jumpTime     = 0.9
+var (
+	seed       = flag.Int64("seed", 0, "seed for obstacle placement, 0 picks a new one at random for every run")
+	recordPath = flag.String("record", "", "record every frame of input to this replay file")
+	replayPath = flag.String("replay", "", "play back a replay file instead of reading the keyboard")
+	configPath = flag.String("config", "", "load game tuning from this JSON file")
 )
 
-// Scene represents the root game scene. The scene references graphic resources, objects and game state.
+// hitboxAlpha is the alpha a sprite pixel must exceed to be collided with.
+const hitboxAlpha = 64
+
+// Scene represents the root game scene. The scene references graphic resources and the game world.
 type Scene struct {
-	Window                *pixelgl.Window
-	music                 *audio.Music
-	LastFrameTime         time.Time
-	TimeSinceLastFrame    float64
-	TimeSinceLastObstacle float64
-	CameraPosition        pixel.Vec
-	Player                *Object
-	Obstacles             []*Object
-	Difficulty            float64
-	Level                 float64
-	Sprites               *Sprites
-	Dead                  bool
-	Jumping               bool
-	JumpSpeed             float64
-	GroundSpeed           float64
-	TimeSinceJump         float64
-	Background            *pixel.Sprite
-}
-
-// Object represents an item in the game (player, obstacle, etc...)
-type Object struct {
-	position     pixel.Vec
-	velocity     pixel.Vec
-	jumpvelocity pixel.Vec
-	sprite       *pixel.Sprite
-}
-
-// Sprites are all the images we use
+	Config             config.Config
+	assets             *assets.Assets
+	Window             *pixelgl.Window
+	Scale              float64
+	music              *audio.Music
+	World              *game.World
+	recorder           *game.Recorder
+	recordFile         *os.File
+	replay             *game.Replay
+	replayDone         bool
+	Timestep           *game.Timestep
+	LastFrameTime      time.Time
+	TimeSinceLastFrame float64
+	CameraPosition     pixel.Vec
+	Sprites            *Sprites
+	Background         *pixel.Sprite
+	ambient            map[*game.Object]*audio.Source
+	watcher            *watch.Watcher
+	highScores         *highscore.Table
+	highScoresPath     string
+	bindings           input.Bindings
+	bindingsPath       string
+	State              State
+}
+
+// Sprites are all the images we use. They are all cut from one atlas, so
+// obstacles can be drawn in a single batch.
 type Sprites struct {
-	forward   *pixel.Sprite
-	left      *pixel.Sprite
-	right     *pixel.Sprite
-	server    *pixel.Sprite
-	harddrive *pixel.Sprite
-	jump      *pixel.Sprite
-	jumpleft  *pixel.Sprite
-	jumpright *pixel.Sprite
-	wipeout   *pixel.Sprite
+	player    *graphics.Animator
+	server    *graphics.Animator
+	harddrive *graphics.Animator
 	tomcruise *pixel.Sprite
+	obstacles *pixel.Batch
+	hitboxes  game.Hitboxes
 }
 
 func main() {
+	flag.Parse()
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
 	pixelgl.Run(renderLoop)
 }
 
 func renderLoop() {
 	scene := initializeScene()
 
+	defer closeRecording(scene)
+	defer scene.watcher.Close()
+	defer scene.music.Close()
+
+	// Replays have no one at the keyboard to work the menus.
+	var first State = newTitleState()
+	if scene.replay != nil {
+		first = &playingState{}
+	}
+	setState(scene, first)
+
 	for !scene.Window.Closed() {
 		scene.TimeSinceLastFrame = time.Since(scene.LastFrameTime).Seconds()
 		scene.LastFrameTime = time.Now()
-		scene.TimeSinceLastObstacle += scene.TimeSinceLastFrame
+		reloadChanged(scene)
 		// Call the render pipeline.
-		processInput(scene)
-		updateState(scene)
-		render(scene)
+		setState(scene, scene.State.Update(scene, scene.TimeSinceLastFrame))
+		scene.State.Render(scene)
+		scene.Window.Update()
 	}
 }
 
-func updateScore(scene *Scene) {
-	distance := scene.Player.position.Y
-	score := distance * -1 / 3
-	if distance > 0 {
-		score = 0
-	}
-
-	basicAtlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
-	basicTxt := text.New(scene.CameraPosition.Add(pixel.V(750, 725)), basicAtlas)
-	basicTxt.Color = colornames.Black
-	fmt.Fprintf(basicTxt, "Score: %s\n", strconv.FormatFloat(score, 'f', 0, 64))
-	fmt.Fprintf(basicTxt, "Level: %v\n", scene.Level)
-	// fmt.Fprintf(basicTxt, "Obstacle Rate: %v\n", strconv.FormatFloat(scene.Difficulty, 'f', 3, 64))
-	basicTxt.Draw(scene.Window, pixel.IM.Scaled(basicTxt.Orig, 2))
+// startRun begins a new run, on a new slope unless the seed is pinned. The
+// restart goes through step so that it is recorded along with the rest of
+// the input.
+func startRun(scene *Scene) {
+	stopSounds(scene)
+	if !seedPinned(scene) {
+		scene.World.Seed = randomSeed()
+	}
+	step(scene, game.Input{Restart: true})
 }
 
-func increaseDifficulty(scene *Scene) {
-	scene.Difficulty -= scene.TimeSinceLastFrame * .05
+// seedPinned reports whether every run uses the same seed: the one given
+// with -seed, or the one a replay was recorded with. Recordings pin it too,
+// since a replay file holds a single seed.
+func seedPinned(scene *Scene) bool {
+	return *seed != 0 || scene.replay != nil || scene.recorder != nil
+}
 
-	if scene.Difficulty < 0.5 {
-		scene.Level++
-		scene.Difficulty = 1
-		scene.GroundSpeed = scene.GroundSpeed * 1.5
-		scene.JumpSpeed = scene.JumpSpeed * 1.5
-		if scene.Jumping {
-			scene.Player.velocity = pixel.V(scene.Player.velocity.X, -scene.JumpSpeed)
-		} else {
-			scene.Player.velocity = pixel.V(scene.Player.velocity.X, -scene.GroundSpeed)
+func randomSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// step advances the game world by one fixed timestep.
+func step(scene *Scene, in game.Input) {
+	dt := scene.Timestep.Step
+	if scene.replay != nil {
+		frame, ok := scene.replay.Next()
+		if !ok {
+			finishReplay(scene)
+			return
 		}
+		in, dt = frame.Input, frame.DT
+	}
+	if scene.recorder != nil {
+		scene.recorder.Record(in, dt)
 	}
+	events := scene.World.Step(in, dt)
+	playEvents(scene, events)
+	placeSounds(scene)
+}
 
+// ambientSounds are the sounds obstacles make, by kind.
+var ambientSounds = map[game.Kind]string{
+	game.Server:    "server_hum",
+	game.HardDrive: "disk_whine",
 }
 
-// processInput is where we process any input events from the keyboard.
-func processInput(scene *Scene) {
-	newVelX := float64(0)
-	if scene.Window.Pressed(pixelgl.KeyLeft) {
-		newVelX -= speed
-		scene.Player.sprite = scene.Sprites.left
-		if scene.Jumping {
-			scene.Player.sprite = scene.Sprites.jumpleft
-		}
+// placeSounds moves the sound of every obstacle in earshot to where it is
+// relative to the player. Sounds start as obstacles come within hearing and
+// stop once they are out of it or gone from the world. They all stop when
+// the player crashes.
+func placeSounds(scene *Scene) {
+	if scene.World.Dead {
+		stopSounds(scene)
+		return
 	}
-	if scene.Window.Pressed(pixelgl.KeyRight) {
-		newVelX += speed
-		scene.Player.sprite = scene.Sprites.right
-		if scene.Jumping {
-			scene.Player.sprite = scene.Sprites.jumpright
+	player := scene.World.Player.Position
+	heard := make(map[*game.Object]bool, len(scene.ambient))
+	for _, o := range scene.World.Obstacles {
+		name, ok := ambientSounds[o.Kind]
+		if !ok {
+			continue
 		}
-	}
-	if !scene.Window.Pressed(pixelgl.KeyRight) && !scene.Window.Pressed(pixelgl.KeyLeft) {
-		scene.Player.sprite = scene.Sprites.forward
-		if scene.Jumping {
-			scene.Player.sprite = scene.Sprites.jump
+		offset := o.Position.Sub(player)
+		if offset.Len() >= audio.HearingDistance {
+			continue
+		}
+		source, ok := scene.ambient[o]
+		if !ok {
+			source = scene.music.Ambience.Start(name)
+			scene.ambient[o] = source
 		}
+		source.Place(offset.X, offset.Y)
+		heard[o] = true
 	}
-	if scene.Window.Pressed(pixelgl.KeySpace) && !scene.Jumping {
-		scene.Jumping = true
-		scene.TimeSinceJump = 0
-		scene.Player.velocity = pixel.V(0, -scene.JumpSpeed)
+	for o, source := range scene.ambient {
+		if !heard[o] {
+			source.Stop()
+			delete(scene.ambient, o)
+		}
 	}
-	if scene.Window.Pressed(pixelgl.KeyEnter) && scene.Dead {
-		scene.Dead = false
-		scene.Player.position = scene.Window.Bounds().Center()
-		scene.Jumping = false
-		scene.Difficulty = 1
-		scene.Obstacles = []*Object{}
+}
+
+// stopSounds fades out the sound of every obstacle.
+func stopSounds(scene *Scene) {
+	for o, source := range scene.ambient {
+		source.Stop()
+		delete(scene.ambient, o)
 	}
+}
 
-	player := scene.Player
-	if scene.Dead {
-		player.sprite = scene.Sprites.wipeout
-	} else {
-		player.velocity = pixel.V(newVelX, player.velocity.Y)
-		newX := player.position.X + player.velocity.X*scene.TimeSinceLastFrame
-		newY := player.position.Y + player.velocity.Y*scene.TimeSinceLastFrame
-		player.position = pixel.V(newX, newY)
+// playEvents lets the music react to what happened in a step.
+func playEvents(scene *Scene, events game.Events) {
+	music := scene.music
+	if events.Has(game.Restarted) {
+		music.Restart()
+	}
+	if events.Has(game.Jumped) {
+		music.Jump()
 	}
+	if events.Has(game.LeveledUp) {
+		music.LevelUp(scene.World.Level)
+	}
+	if events.Has(game.Died) {
+		music.PlayDeadSound()
+	}
+}
 
-	camPosX := player.position.X - scene.Window.Bounds().Center().X
-	camPosY := player.position.Y - scene.Window.Bounds().Center().Y
-	scene.CameraPosition = pixel.V(camPosX, camPosY-200)
+// finishReplay reports the outcome of a replay once it has run out of frames.
+func finishReplay(scene *Scene) {
+	if scene.replayDone {
+		return
+	}
+	scene.replayDone = true
+	fmt.Printf("replay finished: score %s, level %v\n",
+		strconv.FormatFloat(scene.World.Score(), 'f', 0, 64), scene.World.Level)
 }
 
-// updateState is where we update any game state.
-// Maybe update scores, object states that aren't related to input.
-func updateState(scene *Scene) {
-	if scene.Dead {
+func closeRecording(scene *Scene) {
+	if scene.recorder == nil {
 		return
 	}
-	if scene.Jumping {
-		scene.TimeSinceJump += scene.TimeSinceLastFrame
-		if scene.TimeSinceJump > jumpTime {
-			scene.Jumping = false
-			scene.Player.velocity = pixel.V(0, -scene.GroundSpeed)
-		}
+	if err := scene.recorder.Close(); err != nil {
+		panic(err)
 	}
-	player := scene.Player
-	var lastIndex int
-	for i, o := range scene.Obstacles {
-		if o.position.Y > player.position.Y+float64(400) {
-			lastIndex = i
-		}
+	if err := scene.recordFile.Close(); err != nil {
+		panic(err)
 	}
-	scene.Obstacles = scene.Obstacles[lastIndex:]
+}
 
-	detectCollisions(scene)
+func updateScore(scene *Scene) {
+	basicTxt := screenText(scene, pixel.V(750, 725))
+	fmt.Fprintf(basicTxt, "Score: %s\n", strconv.FormatFloat(scene.World.Score(), 'f', 0, 64))
+	fmt.Fprintf(basicTxt, "Level: %v\n", scene.World.Level)
+	// fmt.Fprintf(basicTxt, "Obstacle Rate: %v\n", strconv.FormatFloat(scene.World.Difficulty, 'f', 3, 64))
+	basicTxt.Draw(scene.Window, pixel.IM.Scaled(basicTxt.Orig, 2))
+}
 
-	// If it has been 1 second since last obstacle then create a new one
-	if scene.TimeSinceLastObstacle > scene.Difficulty {
-		randX := rand.Intn(2*windowWidth) - windowWidth
-		sprite := scene.Sprites.harddrive
-		if rand.Intn(2) == 0 {
-			sprite = scene.Sprites.server
-		}
-		newObj := &Object{
-			position: player.position.Add(pixel.V(float64(randX), -700)),
-			velocity: pixel.V(0, 0),
-			sprite:   sprite,
-		}
-		scene.Obstacles = append(scene.Obstacles, newObj)
-		scene.TimeSinceLastObstacle = 0
+// processInput is where we read the keyboard into input for the game world,
+// through the player's key bindings. Restarting is left to the game over
+// screen.
+func processInput(scene *Scene) game.Input {
+	return game.Input{
+		Left:  actionPressed(scene, input.SteerLeft),
+		Right: actionPressed(scene, input.SteerRight),
+		Jump:  actionPressed(scene, input.Jump),
 	}
+}
 
-	increaseDifficulty(scene)
+// playerClip picks the animation matching what the player is currently doing.
+func playerClip(world *game.World) string {
+	switch {
+	case world.Dead:
+		return "Wipeout"
+	case world.Steering < 0 && world.Jumping:
+		return "JumpLeft"
+	case world.Steering < 0:
+		return "Left"
+	case world.Steering > 0 && world.Jumping:
+		return "JumpRight"
+	case world.Steering > 0:
+		return "Right"
+	case world.Jumping:
+		return "Jump"
+	default:
+		return "Forward"
+	}
 }
 
-func detectCollisions(scene *Scene) {
-	for _, obstacle := range scene.Obstacles {
-		if obstacle.sprite == scene.Sprites.harddrive && scene.Jumping {
-			continue
-		}
-		if intersectRect(scene.Player, obstacle) {
-			scene.Dead = true
-			go scene.music.PlayDeadSound()
-		}
+// obstacleAnimator picks the animation for the kind of obstacle.
+func obstacleAnimator(scene *Scene, o *game.Object) *graphics.Animator {
+	if o.Kind == game.Server {
+		return scene.Sprites.server
 	}
+	return scene.Sprites.harddrive
 }
 
-func intersectRect(object1 *Object, object2 *Object) bool {
-	// object1Right := object1.position.X + object1.sprite.Frame().W()
-	// object2Right := object2.position.X + object2.sprite.Frame().W()
-	// object1Bottom := object1.position.Y + object1.sprite.Frame().H()
-	// object2Bottom := object2.position.Y + object2.sprite.Frame().H()
+// animate advances every animation by dt seconds. It returns the name of
+// the player's clip if it ended, like "Wipeout" once the crash is over.
+func animate(scene *Scene, dt float64) (playerEnded string) {
+	sprites := scene.Sprites
+	sprites.player.Play(playerClip(scene.World))
+	playerEnded = sprites.player.Update(dt)
+	for _, a := range []*graphics.Animator{sprites.server, sprites.harddrive} {
+		a.Update(dt)
+	}
+	return playerEnded
+}
 
-	// // HACK: We had to account for half of speed for some reason on detecting where
-	// // the player top position is. I have a feeling this has to do with
-	// // detecting collissions after applying speed but it's unclear.
-	// collides := !(object2.position.X > object1Right ||
-	// 	object2Right < object1.position.X ||
-	// 	object2.position.Y > object1Bottom ||
-	// 	object2Bottom < object1.position.Y+(speed/2))
+// renderWorld draws the slope, obstacles, player and score. alpha is how far
+// the frame lies between the last two steps of the world.
+func renderWorld(scene *Scene, alpha float64) {
+	playerPos := scene.World.Player.Interpolated(alpha)
+	camPosX := playerPos.X - view(scene).Center().X
+	camPosY := playerPos.Y - view(scene).Center().Y
+	scene.CameraPosition = pixel.V(camPosX, camPosY-scene.Config.Display.CameraOffset)
 
-	// // Used for debug purposes.
-	// // fmt.Printf("[TOP: %4.0f BOTTOM: %4.0f LEFT: %4.0f RIGHT: %4.0f]\t[TOP: %4.0f BOTTOM: %4.0f LEFT: %4.0f RIGHT: %4.0f]\n",
-	// // 	object1.position.Y,
-	// // 	object1Bottom,
-	// // 	object1.position.X,
-	// // 	object1Right,
-	// // 	object2.position.Y,
-	// // 	object2Bottom,
-	// // 	object2.position.X,
-	// // 	object2Right)
+	cameraMatrix := pixel.IM.Moved(scene.CameraPosition.Scaled(-1)).Scaled(pixel.ZV, scene.Scale)
+	scene.Window.SetMatrix(cameraMatrix)
 
-	// return collides
+	scene.Window.Clear(colornames.Blueviolet)
+	modx := scene.Config.Display.BackgroundTileWidth
+	mody := scene.Config.Display.BackgroundTileHeight
+	bgoffset := pixel.V(playerPos.X-float64(int(playerPos.X)%modx), playerPos.Y-float64(int(playerPos.Y)%mody))
+	scene.Background.Draw(scene.Window, pixel.IM.Scaled(pixel.V(0, 0), 5).Moved(bgoffset))
 
-	minXOffset := object1.sprite.Frame().W()/2 + object2.sprite.Frame().W()/2
-	minYOffset := object1.sprite.Frame().H()/2 + object2.sprite.Frame().H()/2
+	batch := scene.Sprites.obstacles
+	batch.Clear()
+	for _, o := range scene.World.Obstacles {
+		obstacleAnimator(scene, o).Draw(batch, pixel.IM.Moved(o.Position))
+	}
+	batch.Draw(scene.Window)
+	scene.Sprites.player.Draw(scene.Window, pixel.IM.Moved(playerPos))
 
-	xOffset := (object1.position.X + object1.sprite.Frame().W()/2) - (object2.position.X + object2.sprite.Frame().W()/2)
-	yOffset := (object1.position.Y + object1.sprite.Frame().H()/2) - (object2.position.Y + object2.sprite.Frame().H()/2)
+	updateScore(scene)
+}
 
-	xDiff := minXOffset - math.Abs(xOffset)
-	yDiff := minYOffset - math.Abs(yOffset)
+// loadSprites loads the sprites in the resolution best suited to scale.
+func loadSprites(a *assets.Assets, scale float64) (*Sprites, error) {
+	sheet, clips, err := graphics.LoadSheet(a.FS, a.Sheet("player"))
+	if err != nil {
+		return nil, err
+	}
+	obstacles, obstacleClips, err := graphics.LoadSheet(a.FS, a.Sheet("obstacles"))
+	if err != nil {
+		return nil, err
+	}
+	// Hitboxes always come from the original art, so that a seed or replay
+	// plays out the same whatever screen it runs on.
+	hitboxes := game.Hitboxes{
+		game.Player:    clipMask(sheet, clips, "Forward"),
+		game.Server:    clipMask(obstacles, obstacleClips, "Server"),
+		game.HardDrive: clipMask(obstacles, obstacleClips, "HardDrive"),
+	}
+	if scale > 1 {
+		sheet, clips, err = graphics.LoadScaledSheet(a.FS, a.Sheet("player"), scale)
+		if err != nil {
+			return nil, err
+		}
+		obstacles, obstacleClips, err = graphics.LoadScaledSheet(a.FS, a.Sheet("obstacles"), scale)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tomcruise, err := graphics.LoadPicture(a.FS, a.Sprite("danger_zone"))
+	if err != nil {
+		return nil, err
+	}
 
-	if xDiff >= 0 && yDiff >= 0 {
-		return true
+	atlas := graphics.PackAtlas(map[string]pixel.Picture{
+		"player":      sheet,
+		"obstacles":   obstacles,
+		"danger_zone": tomcruise,
+	})
+	clips = atlas.Clips("player", clips)
+	obstacleClips = atlas.Clips("obstacles", obstacleClips)
+
+	return &Sprites{
+		player:    graphics.NewAnimator(atlas.Picture(), clips, "Forward"),
+		server:    graphics.NewAnimator(atlas.Picture(), obstacleClips, "Server"),
+		harddrive: graphics.NewAnimator(atlas.Picture(), obstacleClips, "HardDrive"),
+		tomcruise: atlas.Sprite("danger_zone"),
+		obstacles: pixel.NewBatch(&pixel.TrianglesData{}, atlas.Picture()),
+		hitboxes:  hitboxes,
+	}, nil
+}
+
+func loadBackground(a *assets.Assets) (*pixel.Sprite, error) {
+	bgPic, err := graphics.LoadPicture(a.FS, a.Sprite("snowtile"))
+	if err != nil {
+		return nil, err
 	}
-	return false
+	return pixel.NewSprite(bgPic, bgPic.Bounds()), nil
 }
 
-// render is where we render graphics after all the input and game state has been processed.
-func render(scene *Scene) {
-	player := scene.Player
-	cameraMatrix := pixel.IM.Moved(scene.CameraPosition.Scaled(-1))
-	scene.Window.SetMatrix(cameraMatrix)
+// clipMask builds a hitbox from the opaque pixels of the first frame of a
+// clip, placed where the frame is drawn relative to its pivot.
+func clipMask(sheet pixel.Picture, clips map[string]*graphics.Clip, clip string) *graphics.Mask {
+	frame := clips[clip].Frames[0]
+	return graphics.NewMask(sheet, frame.Rect, hitboxAlpha).Moved(frame.Offset)
+}
 
-	scene.Window.Clear(colornames.Blueviolet)
-	modx := 220
-	mody := 440
-	bgoffset := pixel.V(player.position.X-float64(int(player.position.X)%modx), player.position.Y-float64(int(player.position.Y)%mody))
-	scene.Background.Draw(scene.Window, pixel.IM.Scaled(pixel.V(0, 0), 5).Moved(bgoffset))
+// openSpeaker opens the sound device, or carries on in silence without one.
+func openSpeaker() audio.Output {
+	out, err := device.Open()
+	if err != nil {
+		log.Printf("playing without sound: %v", err)
+		return &audio.Null{}
+	}
+	return out
+}
 
-	for _, o := range scene.Obstacles {
-		o.sprite.Draw(scene.Window, pixel.IM.Moved(o.position))
+// applyAudio sets the volume of every audio bus, and how the playlist plays,
+// from the config.
+func applyAudio(scene *Scene) {
+	cfg := scene.Config.Audio
+	scene.music.Master.SetVolume(cfg.MasterVolume)
+	scene.music.Master.SetMuted(cfg.Muted)
+	scene.music.Music.SetVolume(cfg.MusicVolume)
+	scene.music.SFX.SetVolume(cfg.SFXVolume)
+	scene.music.SetPlaylistOptions(playlistOptions(cfg))
+}
+
+func playlistOptions(cfg config.Audio) audio.PlaylistOptions {
+	return audio.PlaylistOptions{
+		Shuffle:   cfg.Shuffle,
+		Crossfade: time.Duration(cfg.Crossfade * float64(time.Second)),
 	}
-	player.sprite.Draw(scene.Window, pixel.IM.Moved(player.position))
+}
 
-	if scene.Dead {
-		atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
-		basicTxt := text.New(pixel.V(scene.Player.position.X, scene.Player.position.Y), atlas)
-		fmt.Fprintln(basicTxt, "DEAD!!!!")
-		player.sprite.Draw(scene.Window, pixel.IM.Moved(player.position))
-		basicTxt.Draw(scene.Window, pixel.IM.Scaled(basicTxt.Orig, 4))
+// view returns the part of the world the window shows, relative to the
+// camera, in pixels of the game rather than of the screen.
+func view(scene *Scene) pixel.Rect {
+	display := scene.Config.Display
+	return pixel.R(0, 0, display.WindowWidth, display.WindowHeight)
+}
 
-		tomCruiseLocation := pixel.Vec{
-			X: player.position.X,
-			Y: player.position.Y - 400,
-		}
-		scene.Sprites.tomcruise.Draw(scene.Window, pixel.IM.Scaled(pixel.V(0, 0), 2).Moved(tomCruiseLocation))
+// windowBounds returns the size of the window on screen.
+func windowBounds(scene *Scene) pixel.Rect {
+	return pixel.Rect{Max: view(scene).Max.Scaled(scene.Scale)}
+}
 
-		scene.Level = 0
-		scene.Difficulty = 1
-		scene.GroundSpeed = speed
-		scene.JumpSpeed = jumpSpeed
-		scene.Player.velocity = pixel.V(0, -scene.GroundSpeed)
+// contentScale returns how many screen pixels make up one pixel of the game.
+// pixelgl doesn't report the monitor's content scale, so unless the config
+// gives one it is estimated, in steps of a half, from how the resolution and
+// physical size of the primary monitor compare to the 96 DPI desktops were
+// designed for.
+func contentScale(display config.Display) float64 {
+	if display.ContentScale > 0 {
+		return display.ContentScale
 	}
-	updateScore(scene)
-	scene.Window.Update()
+	if len(pixelgl.Monitors()) == 0 {
+		return 1
+	}
+	monitor := pixelgl.PrimaryMonitor()
+	width, _ := monitor.Size()
+	widthMM, _ := monitor.PhysicalSize()
+	if width <= 0 || widthMM <= 0 {
+		return 1
+	}
+	dpi := width / (widthMM / 25.4)
+	return math.Max(1, math.Round(dpi/96*2)/2)
 }
 
-func getSprite(img string) *pixel.Sprite {
-	img = fmt.Sprintf("graphics/dj/%s.png", img)
-	playerSprite, err := graphics.LoadPicture(img)
+func loadReplay(path string) *game.Replay {
+	f, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	replay, err := game.ReadReplay(f)
 	if err != nil {
 		panic(err)
 	}
+	return replay
+}
 
-	return pixel.NewSprite(playerSprite, playerSprite.Bounds())
+func loadConfig() config.Config {
+	if *configPath == "" {
+		return config.Default()
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
 }
 
 func initializeScene() *Scene {
-	scene := &Scene{}
+	scene := &Scene{ambient: make(map[*game.Object]*audio.Source)}
+	scene.Config = loadConfig()
+	display := scene.Config.Display
+	scene.Scale = contentScale(display)
 
-	scene.music = audio.NewMusic()
-	go scene.music.PlayBackgroundMusic()
+	a, assetDir, err := openAssets()
+	if err != nil {
+		panic(err)
+	}
+	scene.assets = a
+
+	scene.music, err = audio.NewMusic(openSpeaker(), a.FS, audio.Files{
+		Dead:     a.Sound("dead"),
+		Playlist: a.Playlist,
+		Ambient: map[string]string{
+			"server_hum": a.Sound("server_hum"),
+			"disk_whine": a.Sound("disk_whine"),
+		},
+	}, playlistOptions(scene.Config.Audio))
+	if err != nil {
+		panic(err)
+	}
+	applyAudio(scene)
+	scene.music.PlayBackgroundMusic()
 
 	// Create the render window.
 	cfg := pixelgl.WindowConfig{
 		Title:  "SNOboard",
-		Bounds: pixel.R(0, 0, windowWidth, windowHeight),
+		Bounds: windowBounds(scene),
 		VSync:  true,
 	}
 	win, err := pixelgl.NewWindow(cfg)
@@ -334,35 +509,54 @@ func initializeScene() *Scene {
 		panic(err)
 	}
 	scene.Window = win
-	scene.GroundSpeed = speed
-	scene.JumpSpeed = jumpSpeed
-	scene.Player = &Object{
-		position: win.Bounds().Center(),
-		velocity: pixel.V(0, -scene.GroundSpeed),
-	}
-
-	scene.Sprites = &Sprites{
-		left:      getSprite("left"),
-		right:     getSprite("right"),
-		forward:   getSprite("forward"),
-		server:    getSprite("serverrack"),
-		harddrive: getSprite("harddrive"),
-		jump:      getSprite("jump"),
-		jumpleft:  getSprite("jumpleft"),
-		jumpright: getSprite("jumpright"),
-		wipeout:   getSprite("wipeout"),
-		tomcruise: getSprite("danger_zone"),
-	}
-
-	img := "graphics/snowtile.png"
-	bgPic, err := graphics.LoadPicture(img)
+
+	scene.Sprites, err = loadSprites(a, scene.Scale)
 	if err != nil {
 		panic(err)
 	}
-	scene.Background = pixel.NewSprite(bgPic, bgPic.Bounds())
 
-	scene.LastFrameTime = time.Now()
+	worldSeed := *seed
+	if worldSeed == 0 {
+		worldSeed = randomSeed()
+	}
+	if *replayPath != "" {
+		scene.replay = loadReplay(*replayPath)
+		worldSeed = scene.replay.Seed
+		if scene.replay.Config != scene.Config.Game {
+			log.Printf("%s was recorded with a different game config, playing it back with that one", *replayPath)
+			scene.Config.Game = scene.replay.Config
+		}
+		if err := scene.replay.CheckHitboxes(scene.Sprites.hitboxes); err != nil {
+			panic(err)
+		}
+	}
+
+	scene.World = game.NewWorld(scene.Config.Game, view(scene).Center(), scene.Sprites.hitboxes, worldSeed)
+
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			panic(err)
+		}
+		scene.recordFile = f
+		scene.recorder = game.NewRecorder(f, scene.World)
+	}
 
-	scene.Difficulty = 1
+	scene.Background, err = loadBackground(a)
+	if err != nil {
+		panic(err)
+	}
+	// Reloading would swap the hitboxes or config a recorded run depends on,
+	// so there is no hot reload while recording or replaying.
+	var watched []string
+	if !fixedRun(scene) {
+		watched = watchedPaths(a, assetDir)
+	}
+	scene.watcher = watch.New(watchInterval, watched...)
+	loadHighScores(scene)
+	loadBindings(scene)
+
+	scene.Timestep = game.NewTimestep(display.StepRate, display.MaxStepsPerFrame)
+	scene.LastFrameTime = time.Now()
 	return scene
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/faiface/pixel/pixelgl"
+	"storj.io/snoboard/assets"
+	"storj.io/snoboard/audio"
+	"storj.io/snoboard/config"
+)
+
+// watchedPaths returns the files and directories on disk to reload into the
+// running game whenever they change. Sprites are watched by directory, to
+// catch the images of sprite sheets and their variants. There is nothing to
+// watch when the assets aren't in a directory.
+func watchedPaths(a *assets.Assets, dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(path string) {
+		path = filepath.Join(dir, path)
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, sprites := range []map[string]string{a.Sprites, a.Sheets} {
+		for _, sprite := range sprites {
+			add(filepath.Dir(filepath.FromSlash(sprite)))
+		}
+	}
+	for _, sound := range a.Sounds {
+		add(filepath.FromSlash(sound))
+	}
+	for _, track := range a.Playlist {
+		add(filepath.FromSlash(track))
+	}
+	return paths
+}
+
+const watchInterval = 500 * time.Millisecond
+
+// fixedRun reports whether the run is being recorded or replayed, and so
+// must keep the config and hitboxes it started with.
+func fixedRun(scene *Scene) bool {
+	return scene.recorder != nil || scene.replay != nil
+}
+
+// reloadChanged swaps in any assets that changed on disk, and the config
+// when F5 is pressed. It runs on the render thread since sprites are GL
+// resources.
+func reloadChanged(scene *Scene) {
+	if scene.Window.JustPressed(pixelgl.KeyF5) {
+		reloadConfig(scene)
+	}
+
+	var sprites, sounds bool
+	for drained := false; !drained; {
+		select {
+		case path := <-scene.watcher.Changes:
+			switch filepath.Ext(path) {
+			case ".png", ".json":
+				sprites = true
+			default:
+				if audio.IsSound(path) {
+					sounds = true
+				}
+			}
+		default:
+			drained = true
+		}
+	}
+
+	if sprites {
+		reloadSprites(scene)
+	}
+	if sounds {
+		if err := scene.music.Reload(); err != nil {
+			log.Printf("error reloading sounds: %v", err)
+		}
+	}
+}
+
+func reloadSprites(scene *Scene) {
+	sprites, err := loadSprites(scene.assets, scene.Scale)
+	if err != nil {
+		log.Printf("error reloading sprites: %v", err)
+		return
+	}
+	background, err := loadBackground(scene.assets)
+	if err != nil {
+		log.Printf("error reloading sprites: %v", err)
+		return
+	}
+	scene.Sprites = sprites
+	scene.Background = background
+	scene.World.Hitboxes = sprites.hitboxes
+}
+
+func reloadConfig(scene *Scene) {
+	if *configPath == "" {
+		log.Print("not reloading config: no -config file given")
+		return
+	}
+	if fixedRun(scene) {
+		log.Print("not reloading config: the run is being recorded or replayed")
+		return
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	scale := contentScale(cfg.Display)
+	rescaled := scale != scene.Scale
+
+	scene.Config = cfg
+	scene.Scale = scale
+	scene.World.Config = cfg.Game
+	scene.Timestep.Step = 1 / cfg.Display.StepRate
+	scene.Timestep.MaxSteps = cfg.Display.MaxStepsPerFrame
+	scene.Window.SetBounds(windowBounds(scene))
+	applyAudio(scene)
+	if rescaled {
+		reloadSprites(scene)
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"storj.io/snoboard/input"
+)
+
+// State is one screen of the game. Each state handles its own input, update
+// and drawing, and the game only moves between states when Update returns a
+// different one.
+type State interface {
+	// Enter is called when the game switches to the state.
+	Enter(scene *Scene)
+	// Update handles input and advances the state by dt seconds. It returns
+	// the state to switch to, or itself to stay.
+	Update(scene *Scene, dt float64) State
+	// Render draws the state.
+	Render(scene *Scene)
+}
+
+// setState switches the game to next if it isn't already the current state.
+func setState(scene *Scene, next State) {
+	if next == scene.State {
+		return
+	}
+	scene.State = next
+	next.Enter(scene)
+}
+
+// screenText starts a block of text at a position on the screen, rather than
+// in the world.
+func screenText(scene *Scene, at pixel.Vec) *text.Text {
+	txt := text.New(scene.CameraPosition.Add(at), atlas)
+	txt.Color = colornames.Black
+	return txt
+}
+
+// menu is a vertical list of items chosen with the arrow keys and return.
+type menu struct {
+	items    []string
+	selected int
+}
+
+// update moves the selection and returns the index of the item chosen this
+// frame, or -1 if none was.
+func (m *menu) update(win *pixelgl.Window) int {
+	if win.JustPressed(pixelgl.KeyUp) {
+		m.selected = (m.selected + len(m.items) - 1) % len(m.items)
+	}
+	if win.JustPressed(pixelgl.KeyDown) {
+		m.selected = (m.selected + 1) % len(m.items)
+	}
+	if win.JustPressed(pixelgl.KeyEnter) {
+		return m.selected
+	}
+	return -1
+}
+
+func (m *menu) render(scene *Scene, at pixel.Vec) {
+	txt := screenText(scene, at)
+	for i, item := range m.items {
+		cursor := "  "
+		if i == m.selected {
+			cursor = "> "
+		}
+		fmt.Fprintf(txt, "%s%s\n", cursor, item)
+	}
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 3))
+}
+
+// titleState is the first screen, where a run is started from.
+type titleState struct {
+	menu menu
+}
+
+func newTitleState() *titleState {
+	return &titleState{menu: menu{items: []string{"Play", "High Scores", "Settings", "Quit"}}}
+}
+
+func (s *titleState) Enter(scene *Scene) {
+	scene.music.Resume()
+}
+
+func (s *titleState) Update(scene *Scene, dt float64) State {
+	switch s.menu.update(scene.Window) {
+	case 0:
+		startRun(scene)
+		return &playingState{}
+	case 1:
+		return &highScoresState{back: s}
+	case 2:
+		return &settingsState{back: s}
+	case 3:
+		scene.Window.SetClosed(true)
+	}
+	return s
+}
+
+func (s *titleState) Render(scene *Scene) {
+	renderWorld(scene, 1)
+	txt := screenText(scene, pixel.V(300, 550))
+	fmt.Fprintln(txt, "SNOboard")
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 6))
+	s.menu.render(scene, pixel.V(380, 400))
+
+	track := scene.music.Track()
+	trackTxt := screenText(scene, pixel.V(380, 150))
+	fmt.Fprintf(trackTxt, "Now playing: %s", strings.TrimSuffix(path.Base(track), path.Ext(track)))
+	trackTxt.Draw(scene.Window, pixel.IM.Scaled(trackTxt.Orig, 2))
+}
+
+// playingState steps the game world while the player rides down the slope.
+type playingState struct{}
+
+func (s *playingState) Enter(scene *Scene) {
+	scene.music.Resume()
+}
+
+func (s *playingState) Update(scene *Scene, dt float64) State {
+	// Pause when asked to, and when the player switches to another window
+	// so they don't come back to a crash.
+	if pausePressed(scene) || !scene.Window.Focused() {
+		return &pausedState{playing: s}
+	}
+
+	in := processInput(scene)
+	steps := scene.Timestep.Advance(dt)
+	for i := 0; i < steps; i++ {
+		step(scene, in)
+	}
+	animate(scene, dt)
+
+	// Replays restart on their own, so only live runs end on a crash.
+	if scene.World.Dead && scene.replay == nil {
+		return newGameOverState(scene)
+	}
+	return s
+}
+
+func (s *playingState) Render(scene *Scene) {
+	renderWorld(scene, scene.Timestep.Alpha())
+}
+
+// pausePressed reports whether one of the keys that pause and resume the game
+// was pressed this frame.
+func pausePressed(scene *Scene) bool {
+	return actionJustPressed(scene, input.Pause)
+}
+
+// pausedState freezes a run, or the crash that ended it, and its sounds
+// until it is resumed. The world isn't stepped while paused, so no time
+// passes in it.
+type pausedState struct {
+	playing State
+	menu    menu
+}
+
+func (s *pausedState) Enter(scene *Scene) {
+	s.menu.items = []string{"Resume", "Skip Track", "Settings", "Quit to Title"}
+	scene.music.Pause()
+}
+
+func (s *pausedState) Update(scene *Scene, dt float64) State {
+	if pausePressed(scene) {
+		return s.playing
+	}
+	switch s.menu.update(scene.Window) {
+	case 0:
+		return s.playing
+	case 1:
+		// The next track fades in once the game is resumed.
+		scene.music.SkipTrack()
+	case 2:
+		return &settingsState{back: s}
+	case 3:
+		stopSounds(scene)
+		return newTitleState()
+	}
+	return s
+}
+
+func (s *pausedState) Render(scene *Scene) {
+	renderWorld(scene, scene.Timestep.Alpha())
+
+	// Wash out the frozen slope behind the menu.
+	overlay := imdraw.New(nil)
+	overlay.Color = pixel.Alpha(0.6)
+	overlay.Push(scene.CameraPosition, scene.CameraPosition.Add(view(scene).Max))
+	overlay.Rectangle(0)
+	overlay.Draw(scene.Window)
+
+	txt := screenText(scene, pixel.V(330, 550))
+	fmt.Fprintln(txt, "PAUSED")
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 6))
+	s.menu.render(scene, pixel.V(380, 400))
+
+	track := scene.music.Track()
+	trackTxt := screenText(scene, pixel.V(380, 150))
+	fmt.Fprintf(trackTxt, "Now playing: %s", strings.TrimSuffix(path.Base(track), path.Ext(track)))
+	trackTxt.Draw(scene.Window, pixel.IM.Scaled(trackTxt.Orig, 2))
+}
+
+// gameOverState shows the crash, asks for a name if the run made the
+// high-score table, and offers to start again.
+type gameOverState struct {
+	entry *nameEntry
+	menu  menu
+	// crashed is set once the wipeout animation has finished.
+	crashed bool
+}
+
+func newGameOverState(scene *Scene) *gameOverState {
+	return &gameOverState{
+		entry: newNameEntry(scene),
+		menu:  menu{items: []string{"Restart", "High Scores", "Title"}},
+	}
+}
+
+func (s *gameOverState) Enter(scene *Scene) {
+	scene.music.Resume()
+}
+
+func (s *gameOverState) Update(scene *Scene, dt float64) State {
+	// Until the wipeout is over the crash, and its sound, pause like the
+	// run does.
+	if !s.crashed && (pausePressed(scene) || !scene.Window.Focused()) {
+		return &pausedState{playing: s}
+	}
+	if animate(scene, dt) == "Wipeout" {
+		s.crashed = true
+	}
+	if !s.crashed {
+		return s
+	}
+
+	if s.entry != nil {
+		if s.entry.update(scene) {
+			s.entry = nil
+			return &highScoresState{back: s}
+		}
+		return s
+	}
+
+	if actionJustPressed(scene, input.Restart) {
+		startRun(scene)
+		return &playingState{}
+	}
+	switch s.menu.update(scene.Window) {
+	case 0:
+		startRun(scene)
+		return &playingState{}
+	case 1:
+		return &highScoresState{back: s}
+	case 2:
+		return newTitleState()
+	}
+	return s
+}
+
+func (s *gameOverState) Render(scene *Scene) {
+	renderWorld(scene, 1)
+	if !s.crashed {
+		return
+	}
+
+	playerPos := scene.World.Player.Position
+	basicTxt := text.New(playerPos, atlas)
+	fmt.Fprintln(basicTxt, "DEAD!!!!")
+	basicTxt.Draw(scene.Window, pixel.IM.Scaled(basicTxt.Orig, 4))
+
+	seedTxt := text.New(playerPos.Sub(pixel.V(0, 40)), atlas)
+	fmt.Fprintf(seedTxt, "Seed: %d\n", scene.World.Seed)
+	seedTxt.Draw(scene.Window, pixel.IM.Scaled(seedTxt.Orig, 2))
+
+	tomCruiseLocation := pixel.Vec{
+		X: playerPos.X,
+		Y: playerPos.Y - 400,
+	}
+	scene.Sprites.tomcruise.Draw(scene.Window, pixel.IM.Scaled(pixel.V(0, 0), 2).Moved(tomCruiseLocation))
+
+	if s.entry != nil {
+		s.entry.render(scene)
+		return
+	}
+	s.menu.render(scene, pixel.V(100, 600))
+}
+
+// highScoresState shows the high-score table.
+type highScoresState struct {
+	back State
+}
+
+func (s *highScoresState) Enter(scene *Scene) {}
+
+func (s *highScoresState) Update(scene *Scene, dt float64) State {
+	win := scene.Window
+	if win.JustPressed(pixelgl.KeyEscape) || win.JustPressed(pixelgl.KeyEnter) {
+		return s.back
+	}
+	return s
+}
+
+func (s *highScoresState) Render(scene *Scene) {
+	renderWorld(scene, 1)
+	renderHighScores(scene)
+}
+
+// settingsState lets the player change how the game runs.
+type settingsState struct {
+	back State
+	menu menu
+}
+
+func (s *settingsState) Enter(scene *Scene) {
+	s.refresh(scene)
+}
+
+// refresh updates the menu to show the current value of every setting.
+func (s *settingsState) refresh(scene *Scene) {
+	volume := scene.Config.Audio
+	s.menu.items = []string{
+		fmt.Sprintf("VSync: %s", onOff(scene.Window.VSync())),
+		fmt.Sprintf("Volume: %s", percent(volume.MasterVolume)),
+		fmt.Sprintf("Music: %s", percent(volume.MusicVolume)),
+		fmt.Sprintf("Sound effects: %s", percent(volume.SFXVolume)),
+		fmt.Sprintf("Mute: %s", onOff(volume.Muted)),
+		"Controls",
+		"Back",
+	}
+}
+
+// volumeStep is how much the volume settings change by per key press.
+const volumeStep = 0.1
+
+func (s *settingsState) Update(scene *Scene, dt float64) State {
+	win := scene.Window
+	s.refresh(scene)
+	if win.JustPressed(pixelgl.KeyEscape) {
+		return s.back
+	}
+
+	// Volumes go down with left and up with right, or round with return.
+	volume := &scene.Config.Audio
+	volumes := map[int]*float64{1: &volume.MasterVolume, 2: &volume.MusicVolume, 3: &volume.SFXVolume}
+	if v, ok := volumes[s.menu.selected]; ok {
+		switch {
+		case win.JustPressed(pixelgl.KeyLeft):
+			*v = math.Max(0, *v-volumeStep)
+			applyAudio(scene)
+		case win.JustPressed(pixelgl.KeyRight):
+			*v = math.Min(1, *v+volumeStep)
+			applyAudio(scene)
+		}
+	}
+
+	switch s.menu.update(win) {
+	case 0:
+		win.SetVSync(!win.VSync())
+	case 1, 2, 3:
+		v := volumes[s.menu.selected]
+		if *v += volumeStep; *v > 1+volumeStep/2 {
+			*v = 0
+		}
+		*v = math.Min(1, *v)
+		applyAudio(scene)
+	case 4:
+		volume.Muted = !volume.Muted
+		applyAudio(scene)
+	case 5:
+		return &controlsState{back: s}
+	case 6:
+		return s.back
+	}
+	return s
+}
+
+func (s *settingsState) Render(scene *Scene) {
+	renderWorld(scene, 1)
+	txt := screenText(scene, pixel.V(300, 550))
+	fmt.Fprintln(txt, "SETTINGS")
+	txt.Draw(scene.Window, pixel.IM.Scaled(txt.Orig, 6))
+	s.menu.render(scene, pixel.V(300, 400))
+}
+
+// percent formats a volume from 0 to 1 as a percentage.
+func percent(volume float64) string {
+	return fmt.Sprintf("%.0f%%", volume*100)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,85 @@
+// Package watch notices when files change on disk by polling them, so the
+// game can reload assets while it runs.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls files and directories and reports files that were created
+// or modified since the last poll.
+type Watcher struct {
+	// Changes receives the path of every file that changed.
+	Changes chan string
+
+	paths   []string
+	stamps  map[string]stamp
+	scanned bool
+	done    chan struct{}
+}
+
+// New starts watching the given files and directories, including everything
+// below the directories, every interval.
+func New(interval time.Duration, paths ...string) *Watcher {
+	w := &Watcher{
+		Changes: make(chan string, 64),
+		paths:   paths,
+		stamps:  make(map[string]stamp),
+		done:    make(chan struct{}),
+	}
+	w.scan(nil)
+	go w.run(interval)
+	return w
+}
+
+// Close stops watching.
+func (w *Watcher) Close() {
+	close(w.done)
+}
+
+func (w *Watcher) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.scan(func(path string) {
+				select {
+				case w.Changes <- path:
+				case <-w.done:
+				}
+			})
+		}
+	}
+}
+
+// scan stats every watched file and calls changed for each one that differs
+// from the previous scan. The first scan only records what is there.
+func (w *Watcher) scan(changed func(path string)) {
+	for _, root := range w.paths {
+		_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				// Missing files may appear later, so keep going.
+				return nil
+			}
+			s := stamp{modTime: info.ModTime(), size: info.Size()}
+			if old, ok := w.stamps[path]; !ok || old != s {
+				w.stamps[path] = s
+				if w.scanned {
+					changed(path)
+				}
+			}
+			return nil
+		})
+	}
+	w.scanned = true
+}
@@ -0,0 +1,74 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sprite.png")
+	tmp := filepath.Join(t.TempDir(), "sprite.png")
+
+	// Poll by hand rather than waiting on the ticker, so a scan never lands
+	// halfway through a write.
+	w := New(time.Hour, path)
+	defer w.Close()
+	scan := func() []string {
+		var changed []string
+		w.scan(func(path string) { changed = append(changed, path) })
+		return changed
+	}
+	write := func(path, data string) {
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name   string
+		change func()
+	}{
+		{"create", func() { write(path, "one") }},
+		{"modify", func() { write(path, "two two") }},
+		{"rename over", func() {
+			write(tmp, "three three three")
+			if err := os.Rename(tmp, path); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	if changed := scan(); len(changed) != 0 {
+		t.Fatalf("changes %v before anything changed", changed)
+	}
+	for _, step := range steps {
+		step.change()
+		if changed := scan(); len(changed) != 1 || changed[0] != path {
+			t.Errorf("%s: got changes %v, want just %s", step.name, changed, path)
+		}
+		if changed := scan(); len(changed) != 0 {
+			t.Errorf("%s: got changes %v on the next scan, want none", step.name, changed)
+		}
+	}
+}
+
+func TestWatcherChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "music.mp3")
+	w := New(10*time.Millisecond, filepath.Dir(path))
+	defer w.Close()
+
+	if err := ioutil.WriteFile(path, []byte("track"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-w.Changes:
+		if got != path {
+			t.Errorf("got change %s, want %s", got, path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}